@@ -22,7 +22,7 @@ import (
 
 func main() {
 	rootCommand := &cobra.Command{
-		Use:   "pql [options] [FILE [...]]",
+		Use:   "pql [options] [FILE [...]]\n  pql [options] -e QUERY",
 		Short: "Translate Pipeline Query Language into SQL",
 
 		DisableFlagsInUseLine: true,
@@ -30,8 +30,14 @@ func main() {
 		SilenceUsage:          true,
 	}
 	outputPath := rootCommand.Flags().StringP("output", "o", "", "file to write SQL to (defaults to stdout)")
+	expression := rootCommand.Flags().StringP("expression", "e", "", "query to translate instead of reading from files")
+	quiet := rootCommand.Flags().BoolP("quiet", "q", false, "do not print informational messages")
 	rootCommand.RunE = func(cmd *cobra.Command, args []string) (err error) {
-		input, err := makeInput(args)
+		var exprArg *string
+		if cmd.Flags().Changed("expression") {
+			exprArg = expression
+		}
+		input, err := makeInput(exprArg, args)
 		if err != nil {
 			return err
 		}
@@ -41,6 +47,7 @@ func main() {
 			return err
 		}
 
+		printTerminalNudge(os.Stderr, isTerminal(input), *quiet)
 		err = run(cmd.Context(), output, input, func(err error) {
 			fmt.Fprintf(os.Stderr, "pql: %v\n", err)
 		})
@@ -64,11 +71,6 @@ func run(ctx context.Context, output io.Writer, input io.Reader, logError func(e
 	scanner := bufio.NewScanner(input)
 	sb := new(strings.Builder)
 
-	if isTerminal(input) {
-		// Nudge for usage if running interactively.
-		fmt.Fprintln(os.Stderr, "Reading from terminal (use semicolons to end statements)...")
-	}
-
 	var finalError error
 	letStatements := new(strings.Builder)
 	for scanner.Scan() {
@@ -108,7 +110,7 @@ func run(ctx context.Context, output io.Writer, input io.Reader, logError func(e
 	}
 
 	if stmt := sb.String(); len(parser.Scan(stmt)) > 0 {
-		sql, err := pql.Compile(stmt)
+		sql, err := pql.Compile(letStatements.String() + stmt)
 		if err != nil {
 			logError(err)
 			return errors.New("one or more statements could not be compiled")
@@ -119,7 +121,24 @@ func run(ctx context.Context, output io.Writer, input io.Reader, logError func(e
 	return finalError
 }
 
-func makeInput(args []string) (io.ReadCloser, error) {
+// printTerminalNudge writes a usage hint to w
+// if the input is interactive and quiet is false.
+func printTerminalNudge(w io.Writer, interactive, quiet bool) {
+	if quiet || !interactive {
+		return
+	}
+	fmt.Fprintln(w, "Reading from terminal (use semicolons to end statements)...")
+}
+
+// makeInput returns the reader for the program's queries.
+// If expression is not nil, then it is used as the sole input.
+func makeInput(expression *string, args []string) (io.ReadCloser, error) {
+	if expression != nil {
+		if len(args) > 0 {
+			return nil, errors.New("cannot use --expression with file arguments")
+		}
+		return nopReadCloser{strings.NewReader(*expression)}, nil
+	}
 	if len(args) == 0 || len(args) == 1 && args[0] == "-" {
 		return nopReadCloser{os.Stdin}, nil
 	}
@@ -17,6 +17,11 @@ func TestRun(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	const letInput = "let threshold = 500;\nStormEvents | where DamageProperty > threshold"
+	letOutputStatement, err := pql.Compile(letInput)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	tests := []struct {
 		name   string
@@ -43,6 +48,22 @@ func TestRun(t *testing.T) {
 			input:  inputStatement + "\n",
 			output: outputStatement + "\n\n",
 		},
+		{
+			name:   "LetBeforeStatement",
+			input:  letInput + ";\n",
+			output: letOutputStatement + "\n\n",
+		},
+		{
+			name:   "LetBeforeUnterminatedStatement",
+			input:  letInput + "\n",
+			output: letOutputStatement + "\n\n",
+		},
+		{
+			name:   "LetRedefined",
+			input:  "let threshold = 500;\nlet threshold = 1;\nStormEvents | where DamageProperty > threshold;\n",
+			output: letOutputStatement + "\n\n",
+			fail:   true,
+		},
 		{
 			name:  "BadStatement",
 			input: "!",
@@ -68,3 +89,50 @@ func TestRun(t *testing.T) {
 		})
 	}
 }
+
+func TestMakeInputExpression(t *testing.T) {
+	const query = "StormEvents | take 5"
+	want, err := pql.Compile(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expr := query
+	input, err := makeInput(&expr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer input.Close()
+	got := new(strings.Builder)
+	if err := run(context.Background(), got, input, func(error) {}); err != nil {
+		t.Error("run:", err)
+	}
+	if got.String() != want+"\n\n" {
+		t.Errorf("output = %q; want %q", got, want+"\n\n")
+	}
+
+	if input, err := makeInput(&expr, []string{"foo.pql"}); err == nil {
+		input.Close()
+		t.Error("makeInput with expression and file arguments did not return an error")
+	}
+}
+
+func TestPrintTerminalNudge(t *testing.T) {
+	tests := []struct {
+		interactive bool
+		quiet       bool
+		want        bool
+	}{
+		{interactive: false, quiet: false, want: false},
+		{interactive: false, quiet: true, want: false},
+		{interactive: true, quiet: false, want: true},
+		{interactive: true, quiet: true, want: false},
+	}
+	for _, test := range tests {
+		sb := new(strings.Builder)
+		printTerminalNudge(sb, test.interactive, test.quiet)
+		if got := sb.Len() > 0; got != test.want {
+			t.Errorf("printTerminalNudge(w, %t, %t) wrote %q; want output = %t", test.interactive, test.quiet, sb, test.want)
+		}
+	}
+}
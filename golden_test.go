@@ -15,6 +15,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/runreveal/pql/parser"
 	"github.com/tailscale/hujson"
 )
 
@@ -74,6 +75,35 @@ func TestGoldens(t *testing.T) {
 	}
 }
 
+// FuzzCompile checks that any query that parses,
+// and any statement that [parser.SplitStatements] splits from it,
+// can be passed to Compile without panicking.
+func FuzzCompile(f *testing.F) {
+	tests, err := findGoldenTests()
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, test := range tests {
+		input, err := test.input()
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(input)
+	}
+
+	f.Fuzz(func(t *testing.T, source string) {
+		sources := append([]string{source}, parser.SplitStatements(source)...)
+		for _, s := range sources {
+			if _, err := parser.Parse(s); err != nil {
+				continue
+			}
+			if _, err := Compile(s); err != nil {
+				t.Logf("Compile(%q): %v", s, err)
+			}
+		}
+	})
+}
+
 type goldenTest struct {
 	name      string
 	dir       string
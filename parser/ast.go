@@ -95,7 +95,8 @@ func (x *TabularExpr) Span() Span {
 
 // TabularDataSource is the interface implemented by all AST node types
 // that can be used as the data source of a [TabularExpr].
-// At the moment, this can only be a [TableRef].
+// At the moment, this can be a [TableRef], a [RangeSource], a [PrintSource],
+// a [DatatableSource], or an [ExternalDataSource].
 type TabularDataSource interface {
 	Node
 	tabularDataSource()
@@ -104,7 +105,11 @@ type TabularDataSource interface {
 // A TableRef node refers to a specific table.
 // It implements [TabularDataSource].
 type TableRef struct {
-	Table *Ident
+	// Qualifiers is the sequence of dot-separated identifiers
+	// that precede the table name (e.g. a database name).
+	// It is empty if the table name is unqualified.
+	Qualifiers []*Ident
+	Table      *Ident
 }
 
 func (ref *TableRef) tabularDataSource() {}
@@ -113,7 +118,144 @@ func (ref *TableRef) Span() Span {
 	if ref == nil {
 		return nullSpan()
 	}
-	return ref.Table.Span()
+	return unionSpans(nodeSliceSpan(ref.Qualifiers), ref.Table.Span())
+}
+
+// A RangeSource node represents a `range` data source,
+// which generates a single-column table of evenly spaced values.
+// It implements [TabularDataSource].
+type RangeSource struct {
+	Keyword  Span
+	Column   *Ident
+	From     Span
+	Start    Expr
+	To       Span
+	Stop     Expr
+	Step     Span
+	StepSize Expr
+}
+
+func (src *RangeSource) tabularDataSource() {}
+
+func (src *RangeSource) Span() Span {
+	if src == nil {
+		return nullSpan()
+	}
+	return unionSpans(
+		src.Keyword,
+		src.Column.Span(),
+		src.From,
+		nodeSpan(src.Start),
+		src.To,
+		nodeSpan(src.Stop),
+		src.Step,
+		nodeSpan(src.StepSize),
+	)
+}
+
+// A PrintSource node represents a `print` data source,
+// which produces a single row from scalar expressions.
+// It implements [TabularDataSource].
+type PrintSource struct {
+	Keyword Span
+	Cols    []*ExtendColumn
+}
+
+func (src *PrintSource) tabularDataSource() {}
+
+func (src *PrintSource) Span() Span {
+	if src == nil {
+		return nullSpan()
+	}
+	return unionSpans(src.Keyword, nodeSliceSpan(src.Cols))
+}
+
+// A DatatableSource node represents an inline `datatable` data source.
+// It implements [TabularDataSource].
+type DatatableSource struct {
+	Keyword Span
+	Lparen  Span
+	Cols    []*DatatableColumn
+	Rparen  Span
+
+	Lbracket Span
+	// Values is the flat list of cell values in row-major order.
+	// Its length is a multiple of len(Cols).
+	Values   []Expr
+	Rbracket Span
+}
+
+func (src *DatatableSource) tabularDataSource() {}
+
+func (src *DatatableSource) Span() Span {
+	if src == nil {
+		return nullSpan()
+	}
+	return unionSpans(
+		src.Keyword,
+		src.Lparen,
+		nodeSliceSpan(src.Cols),
+		src.Rparen,
+		src.Lbracket,
+		nodeSliceSpan(src.Values),
+		src.Rbracket,
+	)
+}
+
+// An ExternalDataSource node represents an `externaldata` data source,
+// which reads rows from files or URLs outside the database.
+// It implements [TabularDataSource].
+type ExternalDataSource struct {
+	Keyword Span
+	Lparen  Span
+	Cols    []*DatatableColumn
+	Rparen  Span
+
+	Lbracket Span
+	URIs     []Expr
+	Rbracket Span
+
+	// With is the span of the "with" keyword
+	// or a null span if the properties were omitted.
+	With       Span
+	Wlparen    Span
+	Properties []*ExtendColumn
+	Wrparen    Span
+}
+
+func (src *ExternalDataSource) tabularDataSource() {}
+
+func (src *ExternalDataSource) Span() Span {
+	if src == nil {
+		return nullSpan()
+	}
+	return unionSpans(
+		src.Keyword,
+		src.Lparen,
+		nodeSliceSpan(src.Cols),
+		src.Rparen,
+		src.Lbracket,
+		nodeSliceSpan(src.URIs),
+		src.Rbracket,
+		src.With,
+		src.Wlparen,
+		nodeSliceSpan(src.Properties),
+		src.Wrparen,
+	)
+}
+
+// A DatatableColumn is a single column declaration in a [DatatableSource].
+type DatatableColumn struct {
+	Name  *Ident
+	Colon Span
+	Type  *Ident
+}
+
+func (col *DatatableColumn) Span() Span {
+	if col == nil {
+		return nullSpan()
+	}
+	return unionSpans(col.Name.Span(), col.Colon, col.Type.Span())
 }
 
 // TabularOperator is the interface implemented by all AST node types
@@ -128,11 +270,50 @@ type TabularOperator interface {
 type CountOperator struct {
 	Pipe    Span
 	Keyword Span
+
+	// As is the span of the "as" keyword
+	// or a null span if the output column was not named.
+	As Span
+	// Name is the name of the output column.
+	// If absent, the column is named "count()".
+	Name *Ident
 }
 
 func (op *CountOperator) tabularOperator() {}
 
 func (op *CountOperator) Span() Span {
+	if op == nil {
+		return nullSpan()
+	}
+	return unionSpans(op.Pipe, op.Keyword, op.As, op.Name.Span())
+}
+
+// GetSchemaOperator represents a `| getschema` operator in a [TabularExpr].
+// It implements [TabularOperator].
+type GetSchemaOperator struct {
+	Pipe    Span
+	Keyword Span
+}
+
+func (op *GetSchemaOperator) tabularOperator() {}
+
+func (op *GetSchemaOperator) Span() Span {
+	if op == nil {
+		return nullSpan()
+	}
+	return unionSpans(op.Pipe, op.Keyword)
+}
+
+// SerializeOperator represents a `| serialize` operator in a [TabularExpr].
+// It implements [TabularOperator].
+type SerializeOperator struct {
+	Pipe    Span
+	Keyword Span
+}
+
+func (op *SerializeOperator) tabularOperator() {}
+
+func (op *SerializeOperator) Span() Span {
 	if op == nil {
 		return nullSpan()
 	}
@@ -206,6 +387,64 @@ func (op *TakeOperator) Span() Span {
 	return unionSpans(op.Pipe, op.Keyword, nodeSpan(op.RowCount))
 }
 
+// SampleOperator represents a `| sample` operator in a [TabularExpr].
+// It implements [TabularOperator].
+type SampleOperator struct {
+	Pipe     Span
+	Keyword  Span
+	RowCount Expr
+}
+
+func (op *SampleOperator) tabularOperator() {}
+
+func (op *SampleOperator) Span() Span {
+	if op == nil {
+		return nullSpan()
+	}
+	return unionSpans(op.Pipe, op.Keyword, nodeSpan(op.RowCount))
+}
+
+// SkipOperator represents a `| skip` operator in a [TabularExpr].
+// It implements [TabularOperator].
+type SkipOperator struct {
+	Pipe     Span
+	Keyword  Span
+	RowCount Expr
+}
+
+func (op *SkipOperator) tabularOperator() {}
+
+func (op *SkipOperator) Span() Span {
+	if op == nil {
+		return nullSpan()
+	}
+	return unionSpans(op.Pipe, op.Keyword, nodeSpan(op.RowCount))
+}
+
+// SearchOperator represents a `| search` operator in a [TabularExpr].
+// It implements [TabularOperator].
+type SearchOperator struct {
+	Pipe    Span
+	Keyword Span
+	// Column is the column to search in.
+	// If nil, the search applies to all columns.
+	Column *Ident
+	// Colon is the position of the colon after Column.
+	// It is a null span if Column is nil.
+	Colon Span
+	// Term is the string literal to search for.
+	Term *BasicLit
+}
+
+func (op *SearchOperator) tabularOperator() {}
+
+func (op *SearchOperator) Span() Span {
+	if op == nil {
+		return nullSpan()
+	}
+	return unionSpans(op.Pipe, op.Keyword, op.Column.Span(), op.Colon, op.Term.Span())
+}
+
 // TopOperator represents a `| top` operator in a [TabularExpr].
 // It implements [TabularOperator].
 type TopOperator struct {
@@ -333,6 +572,90 @@ func (op *SummarizeColumn) Span() Span {
 	return unionSpans(op.Name.Span(), op.Assign, nodeSpan(op.X))
 }
 
+// InvokeOperator represents a `| invoke` operator in a [TabularExpr].
+// It implements [TabularOperator].
+type InvokeOperator struct {
+	Pipe    Span
+	Keyword Span
+	Call    *CallExpr
+}
+
+func (op *InvokeOperator) tabularOperator() {}
+
+func (op *InvokeOperator) Span() Span {
+	if op == nil {
+		return nullSpan()
+	}
+	return unionSpans(op.Pipe, op.Keyword, op.Call.Span())
+}
+
+// UnionOperator represents a `| union` operator in a [TabularExpr].
+// It implements [TabularOperator].
+type UnionOperator struct {
+	Pipe    Span
+	Keyword Span
+	// Tables is the list of tables whose rows are combined
+	// with the rows of the input.
+	// The order of the combined rows is not specified.
+	Tables []*TabularExpr
+}
+
+func (op *UnionOperator) tabularOperator() {}
+
+func (op *UnionOperator) Span() Span {
+	if op == nil {
+		return nullSpan()
+	}
+	return unionSpans(op.Pipe, op.Keyword, nodeSliceSpan(op.Tables))
+}
+
+// LookupOperator represents a `| lookup` operator in a [TabularExpr].
+// It implements [TabularOperator].
+type LookupOperator struct {
+	Pipe    Span
+	Keyword Span
+
+	Kind       Span
+	KindAssign Span
+	// Flavor is the type of lookup to use.
+	// If absent, leftouter is implied.
+	Flavor *Ident
+
+	// Lparen is the position of the opening parenthesis around Right.
+	// It is a null span if Right is a bare table name.
+	Lparen Span
+	Right  *TabularExpr
+	// Rparen is the position of the closing parenthesis around Right.
+	// It is a null span if Right is a bare table name.
+	Rparen Span
+
+	On Span
+	// Conditions is one or more AND-ed conditions.
+	// If the expression is a single identifier x,
+	// then it is treated as equivalent to "$left.x == $right.x".
+	Conditions []Expr
+}
+
+func (op *LookupOperator) tabularOperator() {}
+
+func (op *LookupOperator) Span() Span {
+	if op == nil {
+		return nullSpan()
+	}
+	return unionSpans(
+		op.Pipe,
+		op.Keyword,
+		op.Kind,
+		op.KindAssign,
+		op.Flavor.Span(),
+		op.Lparen,
+		op.Right.Span(),
+		op.Rparen,
+		op.On,
+		nodeSliceSpan(op.Conditions),
+	)
+}
+
 // JoinOperator represents a `| join` operator in a [TabularExpr].
 // It implements [TabularOperator].
 type JoinOperator struct {
@@ -426,13 +749,19 @@ func (expr *UnaryExpr) Span() Span {
 
 func (expr *UnaryExpr) expression() {}
 
-// An InExpr represents an "in" operator expression.
+// An InExpr represents an "in", "in~", "!in", or "!in~" operator expression.
 type InExpr struct {
 	X      Expr
 	In     Span
 	Lparen Span
 	Vals   []Expr
 	Rparen Span
+
+	// CaseInsensitive is true if the operator is "in~" or "!in~",
+	// which compare strings without regard to case.
+	CaseInsensitive bool
+	// Negated is true if the operator is "!in" or "!in~".
+	Negated bool
 }
 
 func (expr *InExpr) Span() Span {
@@ -605,8 +934,66 @@ func Walk(n Node, visit func(n Node) bool) {
 		case *TableRef:
 			if visit(n) {
 				stack = append(stack, n.Table)
+				for i := len(n.Qualifiers) - 1; i >= 0; i-- {
+					stack = append(stack, n.Qualifiers[i])
+				}
+			}
+		case *RangeSource:
+			if visit(n) {
+				if n.StepSize != nil {
+					stack = append(stack, n.StepSize)
+				}
+				if n.Stop != nil {
+					stack = append(stack, n.Stop)
+				}
+				if n.Start != nil {
+					stack = append(stack, n.Start)
+				}
+				if n.Column != nil {
+					stack = append(stack, n.Column)
+				}
+			}
+		case *PrintSource:
+			if visit(n) {
+				for i := len(n.Cols) - 1; i >= 0; i-- {
+					stack = append(stack, n.Cols[i])
+				}
+			}
+		case *DatatableSource:
+			if visit(n) {
+				for i := len(n.Values) - 1; i >= 0; i-- {
+					stack = append(stack, n.Values[i])
+				}
+				for i := len(n.Cols) - 1; i >= 0; i-- {
+					stack = append(stack, n.Cols[i])
+				}
+			}
+		case *ExternalDataSource:
+			if visit(n) {
+				for i := len(n.Properties) - 1; i >= 0; i-- {
+					stack = append(stack, n.Properties[i])
+				}
+				for i := len(n.URIs) - 1; i >= 0; i-- {
+					stack = append(stack, n.URIs[i])
+				}
+				for i := len(n.Cols) - 1; i >= 0; i-- {
+					stack = append(stack, n.Cols[i])
+				}
+			}
+		case *DatatableColumn:
+			if visit(n) {
+				if n.Type != nil {
+					stack = append(stack, n.Type)
+				}
+				stack = append(stack, n.Name)
 			}
 		case *CountOperator:
+			if visit(n) && n.Name != nil {
+				stack = append(stack, n.Name)
+			}
+		case *GetSchemaOperator:
+			visit(n)
+		case *SerializeOperator:
 			visit(n)
 		case *WhereOperator:
 			if visit(n) {
@@ -626,6 +1013,23 @@ func Walk(n Node, visit func(n Node) bool) {
 			if visit(n) {
 				stack = append(stack, n.RowCount)
 			}
+		case *SampleOperator:
+			if visit(n) {
+				stack = append(stack, n.RowCount)
+			}
+		case *SkipOperator:
+			if visit(n) {
+				stack = append(stack, n.RowCount)
+			}
+		case *SearchOperator:
+			if visit(n) {
+				if n.Term != nil {
+					stack = append(stack, n.Term)
+				}
+				if n.Column != nil {
+					stack = append(stack, n.Column)
+				}
+			}
 		case *TopOperator:
 			if visit(n) {
 				stack = append(stack, n.Col)
@@ -681,6 +1085,26 @@ func Walk(n Node, visit func(n Node) bool) {
 				}
 				stack = append(stack, n.Right)
 			}
+		case *LookupOperator:
+			if visit(n) {
+				// Skipping Flavor because it's more of a keyword on the operator than anything else.
+				for i := len(n.Conditions) - 1; i >= 0; i-- {
+					stack = append(stack, n.Conditions[i])
+				}
+				if n.Right != nil {
+					stack = append(stack, n.Right)
+				}
+			}
+		case *InvokeOperator:
+			if visit(n) && n.Call != nil {
+				stack = append(stack, n.Call)
+			}
+		case *UnionOperator:
+			if visit(n) {
+				for i := len(n.Tables) - 1; i >= 0; i-- {
+					stack = append(stack, n.Tables[i])
+				}
+			}
 		case *AsOperator:
 			if visit(n) {
 				stack = append(stack, n.Name)
@@ -694,6 +1118,10 @@ func Walk(n Node, visit func(n Node) bool) {
 			if visit(n) {
 				stack = append(stack, n.X)
 			}
+		case *ParenExpr:
+			if visit(n) {
+				stack = append(stack, n.X)
+			}
 		case *InExpr:
 			if visit(n) {
 				for i := len(n.Vals) - 1; i >= 0; i-- {
@@ -725,3 +1153,105 @@ func Walk(n Node, visit func(n Node) bool) {
 		}
 	}
 }
+
+// Respan sets every valid span in the syntax tree rooted at n to span.
+// Null spans are left as-is.
+// Respan is useful for attributing nodes parsed from other text
+// (like the body of a function) to a single position in a query.
+func Respan(n Node, span Span) {
+	set := func(spans ...*Span) {
+		for _, s := range spans {
+			if s.IsValid() {
+				*s = span
+			}
+		}
+	}
+	Walk(n, func(n Node) bool {
+		switch n := n.(type) {
+		case *Ident:
+			if n != nil {
+				set(&n.NameSpan)
+			}
+		case *RangeSource:
+			set(&n.Keyword, &n.From, &n.To, &n.Step)
+		case *PrintSource:
+			set(&n.Keyword)
+		case *DatatableSource:
+			set(&n.Keyword, &n.Lparen, &n.Rparen, &n.Lbracket, &n.Rbracket)
+		case *ExternalDataSource:
+			set(&n.Keyword, &n.Lparen, &n.Rparen, &n.Lbracket, &n.Rbracket, &n.With, &n.Wlparen, &n.Wrparen)
+		case *DatatableColumn:
+			set(&n.Colon)
+		case *CountOperator:
+			set(&n.Pipe, &n.Keyword, &n.As)
+		case *GetSchemaOperator:
+			set(&n.Pipe, &n.Keyword)
+		case *SerializeOperator:
+			set(&n.Pipe, &n.Keyword)
+		case *WhereOperator:
+			set(&n.Pipe, &n.Keyword)
+		case *SortOperator:
+			set(&n.Pipe, &n.Keyword)
+		case *SortTerm:
+			set(&n.AscDescSpan, &n.NullsSpan)
+		case *TakeOperator:
+			set(&n.Pipe, &n.Keyword)
+		case *SampleOperator:
+			set(&n.Pipe, &n.Keyword)
+		case *SkipOperator:
+			set(&n.Pipe, &n.Keyword)
+		case *SearchOperator:
+			set(&n.Pipe, &n.Keyword, &n.Colon)
+		case *TopOperator:
+			set(&n.Pipe, &n.Keyword, &n.By)
+		case *ProjectOperator:
+			set(&n.Pipe, &n.Keyword)
+		case *ProjectColumn:
+			set(&n.Assign)
+		case *ExtendOperator:
+			set(&n.Pipe, &n.Keyword)
+		case *ExtendColumn:
+			set(&n.Assign)
+		case *SummarizeOperator:
+			set(&n.Pipe, &n.Keyword, &n.By)
+		case *SummarizeColumn:
+			set(&n.Assign)
+		case *JoinOperator:
+			set(&n.Pipe, &n.Keyword, &n.Kind, &n.KindAssign, &n.Lparen, &n.Rparen, &n.On)
+			if n.Flavor != nil {
+				// Walk does not visit Flavor.
+				set(&n.Flavor.NameSpan)
+			}
+		case *LookupOperator:
+			set(&n.Pipe, &n.Keyword, &n.Kind, &n.KindAssign, &n.Lparen, &n.Rparen, &n.On)
+			if n.Flavor != nil {
+				// Walk does not visit Flavor.
+				set(&n.Flavor.NameSpan)
+			}
+		case *InvokeOperator:
+			set(&n.Pipe, &n.Keyword)
+		case *UnionOperator:
+			set(&n.Pipe, &n.Keyword)
+		case *AsOperator:
+			set(&n.Pipe, &n.Keyword)
+		case *BinaryExpr:
+			set(&n.OpSpan)
+		case *UnaryExpr:
+			set(&n.OpSpan)
+		case *ParenExpr:
+			set(&n.Lparen, &n.Rparen)
+		case *InExpr:
+			set(&n.In, &n.Lparen, &n.Rparen)
+		case *BasicLit:
+			set(&n.ValueSpan)
+		case *CallExpr:
+			// Walk does not visit Func.
+			set(&n.Func.NameSpan, &n.Lparen, &n.Rparen)
+		case *IndexExpr:
+			set(&n.Lbrack, &n.Rbrack)
+		case *LetStatement:
+			set(&n.Keyword, &n.Assign)
+		}
+		return true
+	})
+}
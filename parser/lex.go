@@ -111,6 +111,42 @@ const (
 	// TokenBy is the keyword "in".
 	// The Value will be the empty string.
 	TokenIn
+	// TokenCaseInsensitiveIn is the sequence "in~".
+	// The Value will be the empty string.
+	TokenCaseInsensitiveIn
+	// TokenNotIn is the sequence "!in".
+	// The Value will be the empty string.
+	TokenNotIn
+	// TokenCaseInsensitiveNotIn is the sequence "!in~".
+	// The Value will be the empty string.
+	TokenCaseInsensitiveNotIn
+
+	// The word operators below are never produced by [Scan]:
+	// their words are scanned as [TokenIdentifier]
+	// so that they remain usable as names.
+	// The parser assigns these kinds (see binaryOperatorKind)
+	// to identifiers that appear in binary operator position.
+
+	// TokenContains is the "contains" operator.
+	TokenContains
+	// TokenContainsCS is the "contains_cs" operator.
+	TokenContainsCS
+	// TokenHas is the "has" operator.
+	TokenHas
+	// TokenHasCS is the "has_cs" operator.
+	TokenHasCS
+	// TokenStartsWith is the "startswith" operator.
+	TokenStartsWith
+	// TokenStartsWithCS is the "startswith_cs" operator.
+	TokenStartsWithCS
+	// TokenEndsWith is the "endswith" operator.
+	TokenEndsWith
+	// TokenEndsWithCS is the "endswith_cs" operator.
+	TokenEndsWithCS
+
+	// TokenMatchesRegex is the sequence "matches regex".
+	// The Value will be the empty string.
+	TokenMatchesRegex
 	// TokenBy is the keyword "by".
 	// The Value will be the empty string.
 	TokenBy
@@ -118,6 +154,9 @@ const (
 	// TokenSemi is the semicolon character (";").
 	// The Value will be the empty string.
 	TokenSemi
+	// TokenColon is the colon character (":").
+	// The Value will be the empty string.
+	TokenColon
 
 	// TokenError is a marker for a scan error.
 	// The Value will contain the error message.
@@ -240,6 +279,27 @@ func Scan(query string) []Token {
 					Kind: TokenCaseInsensitiveNE,
 					Span: newSpan(start, s.pos),
 				})
+			case ok && isAlpha(c):
+				// Check for "!in" or "!in~".
+				s.prev()
+				identStart := s.pos
+				switch tok := s.ident(); tok.Kind {
+				case TokenIn:
+					tokens = append(tokens, Token{
+						Kind: TokenNotIn,
+						Span: newSpan(start, s.pos),
+					})
+				case TokenCaseInsensitiveIn:
+					tokens = append(tokens, Token{
+						Kind: TokenCaseInsensitiveNotIn,
+						Span: newSpan(start, s.pos),
+					})
+				default:
+					s.setPos(identStart)
+					tokens = append(tokens,
+						errorToken(newSpan(start, s.pos), "unrecognized token '!'"),
+					)
+				}
 			default:
 				// TODO(maybe): Turn this into logical inversion?
 				// KQL seems to use the not() function.
@@ -327,6 +387,11 @@ func Scan(query string) []Token {
 				Kind: TokenSemi,
 				Span: newSpan(start, s.pos),
 			})
+		case c == ':':
+			tokens = append(tokens, Token{
+				Kind: TokenColon,
+				Span: newSpan(start, s.pos),
+			})
 		default:
 			span := newSpan(start, s.pos)
 			tokens = append(tokens, errorToken(span, "unrecognized character %q", spanString(query, span)))
@@ -379,6 +444,31 @@ func (s *scanner) ident() Token {
 		tok.Kind = kind
 		tok.Value = ""
 	}
+	if tok.Kind == TokenIdentifier && tok.Value == "matches" {
+		// "matches regex" is a two-word operator.
+		// Neither word is reserved on its own.
+		rest := s.s[s.pos:]
+		end := s.pos + len(rest) - len(strings.TrimLeftFunc(rest, unicode.IsSpace))
+		const second = "regex"
+		if end > s.pos && strings.HasPrefix(s.s[end:], second) {
+			end += len(second)
+			if c, _ := utf8.DecodeRuneInString(s.s[end:]); end == len(s.s) || !(isAlpha(c) || isDigit(c) || c == '_') {
+				s.setPos(end)
+				return Token{
+					Kind: TokenMatchesRegex,
+					Span: newSpan(start, end),
+				}
+			}
+		}
+	}
+	if tok.Kind == TokenIn {
+		if c, ok := s.next(); ok && c == '~' {
+			tok.Kind = TokenCaseInsensitiveIn
+			tok.Span = newSpan(start, s.pos)
+		} else if ok {
+			s.prev()
+		}
+	}
 	return tok
 }
 
@@ -4,6 +4,7 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -292,6 +293,34 @@ var lexTests = []struct {
 			{Kind: TokenIdentifier, Span: newSpan(9, 13), Value: "that"},
 		},
 	},
+	{
+		name:  "StringOperatorIdentifiers",
+		query: "x contains_cs y has z",
+		want: []Token{
+			{Kind: TokenIdentifier, Span: newSpan(0, 1), Value: "x"},
+			{Kind: TokenIdentifier, Span: newSpan(2, 13), Value: "contains_cs"},
+			{Kind: TokenIdentifier, Span: newSpan(14, 15), Value: "y"},
+			{Kind: TokenIdentifier, Span: newSpan(16, 19), Value: "has"},
+			{Kind: TokenIdentifier, Span: newSpan(20, 21), Value: "z"},
+		},
+	},
+	{
+		name:  "MatchesRegex",
+		query: `url matches  regex "^https?://"`,
+		want: []Token{
+			{Kind: TokenIdentifier, Span: newSpan(0, 3), Value: "url"},
+			{Kind: TokenMatchesRegex, Span: newSpan(4, 18)},
+			{Kind: TokenString, Span: newSpan(19, 31), Value: "^https?://"},
+		},
+	},
+	{
+		name:  "MatchesIdentifier",
+		query: `matches regexes`,
+		want: []Token{
+			{Kind: TokenIdentifier, Span: newSpan(0, 7), Value: "matches"},
+			{Kind: TokenIdentifier, Span: newSpan(8, 15), Value: "regexes"},
+		},
+	},
 	{
 		name:  "Or",
 		query: "this or that",
@@ -432,6 +461,47 @@ var lexTests = []struct {
 			{Kind: TokenString, Span: newSpan(9, 14), Value: "xyz"},
 		},
 	},
+	{
+		name:  "CaseInsensitiveIn",
+		query: `x in~ ("a")`,
+		want: []Token{
+			{Kind: TokenIdentifier, Span: newSpan(0, 1), Value: "x"},
+			{Kind: TokenCaseInsensitiveIn, Span: newSpan(2, 5)},
+			{Kind: TokenLParen, Span: newSpan(6, 7)},
+			{Kind: TokenString, Span: newSpan(7, 10), Value: "a"},
+			{Kind: TokenRParen, Span: newSpan(10, 11)},
+		},
+	},
+	{
+		name:  "NotIn",
+		query: `x !in ("a")`,
+		want: []Token{
+			{Kind: TokenIdentifier, Span: newSpan(0, 1), Value: "x"},
+			{Kind: TokenNotIn, Span: newSpan(2, 5)},
+			{Kind: TokenLParen, Span: newSpan(6, 7)},
+			{Kind: TokenString, Span: newSpan(7, 10), Value: "a"},
+			{Kind: TokenRParen, Span: newSpan(10, 11)},
+		},
+	},
+	{
+		name:  "CaseInsensitiveNotIn",
+		query: `x !in~ ("a")`,
+		want: []Token{
+			{Kind: TokenIdentifier, Span: newSpan(0, 1), Value: "x"},
+			{Kind: TokenCaseInsensitiveNotIn, Span: newSpan(2, 6)},
+			{Kind: TokenLParen, Span: newSpan(7, 8)},
+			{Kind: TokenString, Span: newSpan(8, 11), Value: "a"},
+			{Kind: TokenRParen, Span: newSpan(11, 12)},
+		},
+	},
+	{
+		name:  "BangIdentifier",
+		query: `!inner`,
+		want: []Token{
+			{Kind: TokenError, Span: newSpan(0, 1), Value: "unrecognized token '!'"},
+			{Kind: TokenIdentifier, Span: newSpan(1, 6), Value: "inner"},
+		},
+	},
 	{
 		name:  "ExpressionList",
 		query: "a, b, c",
@@ -559,3 +629,43 @@ func TestSplitStatements(t *testing.T) {
 		}
 	}
 }
+
+func FuzzSplitStatements(f *testing.F) {
+	for _, test := range lexTests {
+		f.Add(test.query)
+	}
+	for _, test := range parserTests {
+		f.Add(test.query)
+	}
+
+	f.Fuzz(func(t *testing.T, source string) {
+		parts := SplitStatements(source)
+		if got := strings.Join(parts, ";"); got != source {
+			t.Fatalf("strings.Join(SplitStatements(%q), \";\") = %q", source, got)
+		}
+		for i, part := range parts {
+			for _, tok := range Scan(part) {
+				if tok.Kind == TokenSemi {
+					t.Errorf("SplitStatements(%q)[%d] = %q contains a semicolon at %v", source, i, part, tok.Span)
+				}
+			}
+		}
+
+		// Parsing the pieces individually should produce the same statements
+		// as parsing the whole source.
+		stmts, err := Parse(source)
+		var partStmts []Statement
+		var partErr bool
+		for _, part := range parts {
+			got, err := Parse(part)
+			partStmts = append(partStmts, got...)
+			partErr = partErr || err != nil
+		}
+		if len(stmts) != len(partStmts) {
+			t.Errorf("Parse(%q) returned %d statements; parsing each of SplitStatements(%[1]q) returned %d statements", source, len(stmts), len(partStmts))
+		}
+		if (err != nil) != partErr {
+			t.Errorf("Parse(%q) error = %v; parsing each of SplitStatements(%[1]q) failed = %t", source, err, partErr)
+		}
+	})
+}
@@ -90,6 +90,30 @@ func Parse(query string) ([]Statement, error) {
 	return result, nil
 }
 
+// ParseOperators converts a sequence of tabular operators separated by pipes,
+// without a leading pipe or data source
+// (e.g. "where x > 0 | take 5"),
+// into a list of Abstract Syntax Tree (AST) nodes.
+func ParseOperators(source string) ([]TabularOperator, error) {
+	// Parse as if there were a leading pipe.
+	p := &parser{
+		source: source,
+		tokens: append([]Token{{Kind: TokenPipe, Span: indexSpan(0)}}, Scan(source)...),
+	}
+	ops, err := p.tabularOperators()
+	if tok, ok := p.next(); ok {
+		err = joinErrors(err, &parseError{
+			source: source,
+			span:   tok.Span,
+			err:    fmt.Errorf("expected '|', got %s", formatToken(source, tok)),
+		})
+	}
+	if err != nil {
+		return ops, fmt.Errorf("parse pipeline query language: %w", err)
+	}
+	return ops, nil
+}
+
 func firstParse[T any](productions ...func() (T, error)) (T, error) {
 	for _, p := range productions[:len(productions)-1] {
 		x, err := p()
@@ -137,20 +161,45 @@ func (p *parser) letStatement() (*LetStatement, error) {
 }
 
 func (p *parser) tabularExpr() (*TabularExpr, error) {
-	tableName, err := p.ident()
-	if err != nil {
-		return nil, err
-	}
-	expr := &TabularExpr{
-		Source: &TableRef{Table: tableName},
+	expr := new(TabularExpr)
+	var finalError error
+	switch {
+	case p.atRangeSource():
+		expr.Source, finalError = p.rangeSource()
+	case p.atPrintSource():
+		expr.Source, finalError = p.printSource()
+	case p.atDatatableSource():
+		expr.Source, finalError = p.datatableSource()
+	case p.atExternalDataSource():
+		expr.Source, finalError = p.externalDataSource()
+	default:
+		tableName, err := p.qualifiedIdent()
+		if tableName == nil {
+			return nil, err
+		}
+		n := len(tableName.Parts)
+		expr.Source = &TableRef{
+			Qualifiers: tableName.Parts[:n-1],
+			Table:      tableName.Parts[n-1],
+		}
+		finalError = err
 	}
 
+	ops, err := p.tabularOperators()
+	expr.Operators = ops
+	return expr, joinErrors(finalError, err)
+}
+
+// tabularOperators parses a sequence of tabular operators,
+// each preceded by a pipe.
+func (p *parser) tabularOperators() ([]TabularOperator, error) {
+	var ops []TabularOperator
 	var finalError error
 	for i := 0; ; i++ {
 		pipeToken, _ := p.next()
 		if pipeToken.Kind != TokenPipe {
 			p.prev()
-			return expr, finalError
+			return ops, finalError
 		}
 
 		opParser := p.split(TokenPipe)
@@ -176,61 +225,109 @@ func (p *parser) tabularExpr() (*TabularExpr, error) {
 		case "count":
 			op, err := opParser.countOperator(pipeToken, operatorName)
 			if op != nil {
-				expr.Operators = append(expr.Operators, op)
+				ops = append(ops, op)
+			}
+			finalError = joinErrors(finalError, err)
+		case "getschema":
+			op, err := opParser.getSchemaOperator(pipeToken, operatorName)
+			if op != nil {
+				ops = append(ops, op)
+			}
+			finalError = joinErrors(finalError, err)
+		case "serialize":
+			op, err := opParser.serializeOperator(pipeToken, operatorName)
+			if op != nil {
+				ops = append(ops, op)
 			}
 			finalError = joinErrors(finalError, err)
 		case "where", "filter":
 			op, err := opParser.whereOperator(pipeToken, operatorName)
 			if op != nil {
-				expr.Operators = append(expr.Operators, op)
+				ops = append(ops, op)
 			}
 			finalError = joinErrors(finalError, err)
 		case "sort", "order":
 			op, err := opParser.sortOperator(pipeToken, operatorName)
 			if op != nil {
-				expr.Operators = append(expr.Operators, op)
+				ops = append(ops, op)
 			}
 			finalError = joinErrors(finalError, err)
-		case "take", "limit":
+		case "take", "limit", "head":
 			op, err := opParser.takeOperator(pipeToken, operatorName)
 			if op != nil {
-				expr.Operators = append(expr.Operators, op)
+				ops = append(ops, op)
+			}
+			finalError = joinErrors(finalError, err)
+		case "sample":
+			op, err := opParser.sampleOperator(pipeToken, operatorName)
+			if op != nil {
+				ops = append(ops, op)
+			}
+			finalError = joinErrors(finalError, err)
+		case "skip", "offset":
+			op, err := opParser.skipOperator(pipeToken, operatorName)
+			if op != nil {
+				ops = append(ops, op)
+			}
+			finalError = joinErrors(finalError, err)
+		case "search":
+			op, err := opParser.searchOperator(pipeToken, operatorName)
+			if op != nil {
+				ops = append(ops, op)
 			}
 			finalError = joinErrors(finalError, err)
 		case "top":
 			op, err := opParser.topOperator(pipeToken, operatorName)
 			if op != nil {
-				expr.Operators = append(expr.Operators, op)
+				ops = append(ops, op)
 			}
 			finalError = joinErrors(finalError, err)
 		case "project":
 			op, err := opParser.projectOperator(pipeToken, operatorName)
 			if op != nil {
-				expr.Operators = append(expr.Operators, op)
+				ops = append(ops, op)
 			}
 			finalError = joinErrors(finalError, err)
 		case "extend":
 			op, err := opParser.extendOperator(pipeToken, operatorName)
 			if op != nil {
-				expr.Operators = append(expr.Operators, op)
+				ops = append(ops, op)
 			}
 			finalError = joinErrors(finalError, err)
 		case "summarize":
 			op, err := opParser.summarizeOperator(pipeToken, operatorName)
 			if op != nil {
-				expr.Operators = append(expr.Operators, op)
+				ops = append(ops, op)
 			}
 			finalError = joinErrors(finalError, err)
 		case "join":
 			op, err := opParser.joinOperator(pipeToken, operatorName)
 			if op != nil {
-				expr.Operators = append(expr.Operators, op)
+				ops = append(ops, op)
+			}
+			finalError = joinErrors(finalError, err)
+		case "lookup":
+			op, err := opParser.lookupOperator(pipeToken, operatorName)
+			if op != nil {
+				ops = append(ops, op)
+			}
+			finalError = joinErrors(finalError, err)
+		case "invoke":
+			op, err := opParser.invokeOperator(pipeToken, operatorName)
+			if op != nil {
+				ops = append(ops, op)
+			}
+			finalError = joinErrors(finalError, err)
+		case "union":
+			op, err := opParser.unionOperator(pipeToken, operatorName)
+			if op != nil {
+				ops = append(ops, op)
 			}
 			finalError = joinErrors(finalError, err)
 		case "as":
 			op, err := opParser.asOperator(pipeToken, operatorName)
 			if op != nil {
-				expr.Operators = append(expr.Operators, op)
+				ops = append(ops, op)
 			}
 			finalError = joinErrors(finalError, err)
 		default:
@@ -246,8 +343,380 @@ func (p *parser) tabularExpr() (*TabularExpr, error) {
 	}
 }
 
+// atRangeSource reports whether the next tokens start a range data source.
+// "range" is not a keyword, so a table named range
+// is distinguished by not being followed by an identifier.
+func (p *parser) atRangeSource() bool {
+	return p.pos+1 < len(p.tokens) &&
+		p.tokens[p.pos].Kind == TokenIdentifier &&
+		p.tokens[p.pos].Value == "range" &&
+		(p.tokens[p.pos+1].Kind == TokenIdentifier || p.tokens[p.pos+1].Kind == TokenQuotedIdentifier)
+}
+
+// rangeSource parses a range data source
+// of the form "range Column from Start to Stop step StepSize".
+func (p *parser) rangeSource() (*RangeSource, error) {
+	keyword, _ := p.next()
+	src := &RangeSource{
+		Keyword: keyword.Span,
+		From:    nullSpan(),
+		To:      nullSpan(),
+		Step:    nullSpan(),
+	}
+	var err error
+	src.Column, err = p.ident()
+	if err != nil {
+		return src, makeErrorOpaque(err)
+	}
+
+	src.From, err = p.contextualKeyword("from")
+	if err != nil {
+		return src, err
+	}
+	src.Start, err = p.expr()
+	if err != nil {
+		return src, makeErrorOpaque(err)
+	}
+
+	src.To, err = p.contextualKeyword("to")
+	if err != nil {
+		return src, err
+	}
+	src.Stop, err = p.expr()
+	if err != nil {
+		return src, makeErrorOpaque(err)
+	}
+
+	src.Step, err = p.contextualKeyword("step")
+	if err != nil {
+		return src, err
+	}
+	src.StepSize, err = p.expr()
+	if err != nil {
+		return src, makeErrorOpaque(err)
+	}
+	return src, nil
+}
+
+// atPrintSource reports whether the next tokens start a print data source.
+// "print" is not a keyword, so a table named print
+// is distinguished by being followed by a pipe, a dot, or nothing.
+func (p *parser) atPrintSource() bool {
+	return p.pos+1 < len(p.tokens) &&
+		p.tokens[p.pos].Kind == TokenIdentifier &&
+		p.tokens[p.pos].Value == "print" &&
+		p.tokens[p.pos+1].Kind != TokenPipe &&
+		p.tokens[p.pos+1].Kind != TokenDot
+}
+
+// printSource parses a print data source
+// of the form "print [Name =] Expr [, ...]".
+func (p *parser) printSource() (*PrintSource, error) {
+	keyword, _ := p.next()
+	src := &PrintSource{
+		Keyword: keyword.Span,
+	}
+	for {
+		col, err := p.extendColumn()
+		if err != nil {
+			return src, makeErrorOpaque(err)
+		}
+		src.Cols = append(src.Cols, col)
+
+		sep, ok := p.next()
+		if !ok {
+			return src, nil
+		}
+		if sep.Kind != TokenComma {
+			p.prev()
+			return src, nil
+		}
+	}
+}
+
+// atDatatableSource reports whether the next tokens start a datatable data source.
+func (p *parser) atDatatableSource() bool {
+	return p.pos+1 < len(p.tokens) &&
+		p.tokens[p.pos].Kind == TokenIdentifier &&
+		p.tokens[p.pos].Value == "datatable" &&
+		p.tokens[p.pos+1].Kind == TokenLParen
+}
+
+// datatableSource parses a datatable data source
+// of the form "datatable(Name:Type [, ...]) [Value [, ...]]".
+func (p *parser) datatableSource() (*DatatableSource, error) {
+	keyword, _ := p.next()
+	src := &DatatableSource{
+		Keyword:  keyword.Span,
+		Lparen:   nullSpan(),
+		Rparen:   nullSpan(),
+		Lbracket: nullSpan(),
+		Rbracket: nullSpan(),
+	}
+	var err error
+	src.Lparen, src.Cols, src.Rparen, err = p.columnDeclarations()
+	if err != nil {
+		return src, err
+	}
+
+	lbracket, _ := p.next()
+	if lbracket.Kind != TokenLBracket {
+		p.prev()
+		return src, &parseError{
+			source: p.source,
+			span:   lbracket.Span,
+			err:    fmt.Errorf("expected '[', got %s", formatToken(p.source, lbracket)),
+		}
+	}
+	src.Lbracket = lbracket.Span
+	valuesParser := p.split(TokenRBracket)
+	if len(valuesParser.tokens) > 0 {
+		src.Values, err = valuesParser.exprList()
+		if err == nil {
+			// Permit a trailing comma.
+			if tok, _ := valuesParser.next(); tok.Kind != TokenComma {
+				valuesParser.prev()
+			}
+		}
+		if err = joinErrors(makeErrorOpaque(err), valuesParser.endSplit()); err != nil {
+			return src, err
+		}
+	}
+	rbracket, _ := p.next()
+	if rbracket.Kind != TokenRBracket {
+		return src, &parseError{
+			source: p.source,
+			span:   lbracket.Span,
+			err:    fmt.Errorf("expected ']', got %s", formatToken(p.source, rbracket)),
+		}
+	}
+	src.Rbracket = rbracket.Span
+
+	if len(src.Values)%len(src.Cols) != 0 {
+		return src, &parseError{
+			source: p.source,
+			span:   unionSpans(src.Lbracket, src.Rbracket),
+			err:    fmt.Errorf("datatable: %d values is not a multiple of %d columns", len(src.Values), len(src.Cols)),
+		}
+	}
+	return src, nil
+}
+
+// atExternalDataSource reports whether the next tokens start an externaldata data source.
+func (p *parser) atExternalDataSource() bool {
+	return p.pos+1 < len(p.tokens) &&
+		p.tokens[p.pos].Kind == TokenIdentifier &&
+		p.tokens[p.pos].Value == "externaldata" &&
+		p.tokens[p.pos+1].Kind == TokenLParen
+}
+
+// externalDataSource parses an externaldata data source of the form
+// "externaldata(Name:Type [, ...]) [URI [, ...]] [with (Name=Value [, ...])]".
+func (p *parser) externalDataSource() (*ExternalDataSource, error) {
+	keyword, _ := p.next()
+	src := &ExternalDataSource{
+		Keyword:  keyword.Span,
+		Lparen:   nullSpan(),
+		Rparen:   nullSpan(),
+		Lbracket: nullSpan(),
+		Rbracket: nullSpan(),
+		With:     nullSpan(),
+		Wlparen:  nullSpan(),
+		Wrparen:  nullSpan(),
+	}
+	var err error
+	src.Lparen, src.Cols, src.Rparen, err = p.columnDeclarations()
+	if err != nil {
+		return src, err
+	}
+
+	lbracket, _ := p.next()
+	if lbracket.Kind != TokenLBracket {
+		p.prev()
+		return src, &parseError{
+			source: p.source,
+			span:   lbracket.Span,
+			err:    fmt.Errorf("expected '[', got %s", formatToken(p.source, lbracket)),
+		}
+	}
+	src.Lbracket = lbracket.Span
+	urisParser := p.split(TokenRBracket)
+	src.URIs, err = urisParser.exprList()
+	if err = joinErrors(makeErrorOpaque(err), urisParser.endSplit()); err != nil {
+		return src, err
+	}
+	rbracket, _ := p.next()
+	if rbracket.Kind != TokenRBracket {
+		return src, &parseError{
+			source: p.source,
+			span:   lbracket.Span,
+			err:    fmt.Errorf("expected ']', got %s", formatToken(p.source, rbracket)),
+		}
+	}
+	src.Rbracket = rbracket.Span
+
+	with, _ := p.next()
+	if with.Kind != TokenIdentifier || with.Value != "with" {
+		p.prev()
+		return src, nil
+	}
+	src.With = with.Span
+	wlparen, _ := p.next()
+	if wlparen.Kind != TokenLParen {
+		p.prev()
+		return src, &parseError{
+			source: p.source,
+			span:   wlparen.Span,
+			err:    fmt.Errorf("expected '(', got %s", formatToken(p.source, wlparen)),
+		}
+	}
+	src.Wlparen = wlparen.Span
+	propsParser := p.split(TokenRParen)
+	for {
+		prop, err := propsParser.extendColumn()
+		if prop != nil {
+			src.Properties = append(src.Properties, prop)
+		}
+		if err != nil {
+			return src, makeErrorOpaque(err)
+		}
+		if prop.X == nil {
+			return src, &parseError{
+				source: p.source,
+				span:   prop.Name.Span(),
+				err:    fmt.Errorf("expected '=' after property %s", prop.Name.Name),
+			}
+		}
+		if sep, _ := propsParser.next(); sep.Kind != TokenComma {
+			propsParser.prev()
+			break
+		}
+	}
+	if err := propsParser.endSplit(); err != nil {
+		return src, err
+	}
+	wrparen, _ := p.next()
+	if wrparen.Kind != TokenRParen {
+		return src, &parseError{
+			source: p.source,
+			span:   wlparen.Span,
+			err:    fmt.Errorf("expected ')', got %s", formatToken(p.source, wrparen)),
+		}
+	}
+	src.Wrparen = wrparen.Span
+	return src, nil
+}
+
+// columnDeclarations parses a parenthesized list of the form "(Name:Type [, ...])".
+func (p *parser) columnDeclarations() (lparen Span, cols []*DatatableColumn, rparen Span, err error) {
+	lparen, rparen = nullSpan(), nullSpan()
+	tok, _ := p.next()
+	if tok.Kind != TokenLParen {
+		p.prev()
+		return lparen, nil, rparen, &parseError{
+			source: p.source,
+			span:   tok.Span,
+			err:    fmt.Errorf("expected '(', got %s", formatToken(p.source, tok)),
+		}
+	}
+	lparen = tok.Span
+
+	colsParser := p.split(TokenRParen)
+	for {
+		col, err := colsParser.datatableColumn()
+		if col != nil {
+			cols = append(cols, col)
+		}
+		if err != nil {
+			return lparen, cols, rparen, makeErrorOpaque(err)
+		}
+		if sep, _ := colsParser.next(); sep.Kind != TokenComma {
+			colsParser.prev()
+			break
+		}
+	}
+	if err := colsParser.endSplit(); err != nil {
+		return lparen, cols, rparen, err
+	}
+	tok, _ = p.next()
+	if tok.Kind != TokenRParen {
+		return lparen, cols, rparen, &parseError{
+			source: p.source,
+			span:   lparen,
+			err:    fmt.Errorf("expected ')', got %s", formatToken(p.source, tok)),
+		}
+	}
+	rparen = tok.Span
+	return lparen, cols, rparen, nil
+}
+
+func (p *parser) datatableColumn() (*DatatableColumn, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	col := &DatatableColumn{
+		Name:  name,
+		Colon: nullSpan(),
+	}
+	colon, _ := p.next()
+	if colon.Kind != TokenColon {
+		p.prev()
+		return col, &parseError{
+			source: p.source,
+			span:   colon.Span,
+			err:    fmt.Errorf("expected ':', got %s", formatToken(p.source, colon)),
+		}
+	}
+	col.Colon = colon.Span
+	col.Type, err = p.ident()
+	if err != nil {
+		return col, makeErrorOpaque(err)
+	}
+	return col, nil
+}
+
+// contextualKeyword consumes an identifier token with the given name,
+// returning an error if the next token is anything else.
+func (p *parser) contextualKeyword(name string) (Span, error) {
+	tok, _ := p.next()
+	if tok.Kind != TokenIdentifier || tok.Value != name {
+		p.prev()
+		return nullSpan(), &parseError{
+			source: p.source,
+			span:   tok.Span,
+			err:    fmt.Errorf("expected '%s', got %s", name, formatToken(p.source, tok)),
+		}
+	}
+	return tok.Span, nil
+}
+
 func (p *parser) countOperator(pipe, keyword Token) (*CountOperator, error) {
-	return &CountOperator{
+	op := &CountOperator{
+		Pipe:    pipe.Span,
+		Keyword: keyword.Span,
+		As:      nullSpan(),
+	}
+	as, _ := p.next()
+	if as.Kind != TokenIdentifier || as.Value != "as" {
+		p.prev()
+		return op, nil
+	}
+	op.As = as.Span
+	var err error
+	op.Name, err = p.ident()
+	return op, makeErrorOpaque(err)
+}
+
+func (p *parser) getSchemaOperator(pipe, keyword Token) (*GetSchemaOperator, error) {
+	return &GetSchemaOperator{
+		Pipe:    pipe.Span,
+		Keyword: keyword.Span,
+	}, nil
+}
+
+func (p *parser) serializeOperator(pipe, keyword Token) (*SerializeOperator, error) {
+	return &SerializeOperator{
 		Pipe:    pipe.Span,
 		Keyword: keyword.Span,
 	}, nil
@@ -381,6 +850,76 @@ func (p *parser) takeOperator(pipe, keyword Token) (*TakeOperator, error) {
 	return op, nil
 }
 
+func (p *parser) sampleOperator(pipe, keyword Token) (*SampleOperator, error) {
+	op := &SampleOperator{
+		Pipe:    pipe.Span,
+		Keyword: keyword.Span,
+	}
+	var err error
+	op.RowCount, err = p.rowCount()
+	if err != nil {
+		return op, makeErrorOpaque(err)
+	}
+	return op, nil
+}
+
+func (p *parser) skipOperator(pipe, keyword Token) (*SkipOperator, error) {
+	op := &SkipOperator{
+		Pipe:    pipe.Span,
+		Keyword: keyword.Span,
+	}
+	var err error
+	op.RowCount, err = p.rowCount()
+	if err != nil {
+		return op, makeErrorOpaque(err)
+	}
+	return op, nil
+}
+
+func (p *parser) searchOperator(pipe, keyword Token) (*SearchOperator, error) {
+	op := &SearchOperator{
+		Pipe:    pipe.Span,
+		Keyword: keyword.Span,
+		Colon:   nullSpan(),
+	}
+
+	tok, _ := p.next()
+	if tok.Kind == TokenIdentifier || tok.Kind == TokenQuotedIdentifier {
+		p.prev()
+		var err error
+		op.Column, err = p.ident()
+		if err != nil {
+			return op, makeErrorOpaque(err)
+		}
+		tok, _ = p.next()
+		if tok.Kind != TokenColon {
+			p.prev()
+			return op, &parseError{
+				source: p.source,
+				span:   tok.Span,
+				err:    fmt.Errorf("expected ':', got %s", formatToken(p.source, tok)),
+			}
+		}
+		op.Colon = tok.Span
+		tok, _ = p.next()
+	}
+
+	if tok.Kind != TokenString {
+		p.prev()
+		return op, &parseError{
+			source: p.source,
+			span:   tok.Span,
+			err:    fmt.Errorf("expected string, got %s", formatToken(p.source, tok)),
+		}
+	}
+	op.Term = &BasicLit{
+		ValueSpan: tok.Span,
+		Kind:      tok.Kind,
+		Value:     tok.Value,
+	}
+	return op, nil
+}
+
 func (p *parser) topOperator(pipe, keyword Token) (*TopOperator, error) {
 	op := &TopOperator{
 		Pipe:    pipe.Span,
@@ -732,6 +1271,172 @@ func (p *parser) joinOperator(pipe, keyword Token) (*JoinOperator, error) {
 	return op, finalError
 }
 
+var lookupTypes = map[string]struct{}{
+	"inner":     {},
+	"leftouter": {},
+}
+
+func (p *parser) lookupOperator(pipe, keyword Token) (*LookupOperator, error) {
+	op := &LookupOperator{
+		Pipe:       pipe.Span,
+		Keyword:    keyword.Span,
+		Kind:       nullSpan(),
+		KindAssign: nullSpan(),
+		Lparen:     nullSpan(),
+		Rparen:     nullSpan(),
+		On:         nullSpan(),
+	}
+
+	// Optional "kind = LookupFlavor" clause.
+	var finalError error
+	tok, _ := p.next()
+	if tok.Kind == TokenIdentifier && tok.Value == "kind" {
+		op.Kind = tok.Span
+		tok, _ = p.next()
+		if tok.Kind != TokenAssign {
+			return op, &parseError{
+				source: p.source,
+				span:   tok.Span,
+				err:    fmt.Errorf("expected '=', got %s", formatToken(p.source, tok)),
+			}
+		}
+		op.KindAssign = tok.Span
+		tok, _ = p.next()
+		if tok.Kind != TokenIdentifier {
+			return op, &parseError{
+				source: p.source,
+				span:   tok.Span,
+				err:    fmt.Errorf("expected lookup flavor, got %s", formatToken(p.source, tok)),
+			}
+		}
+		op.Flavor = &Ident{
+			Name:     tok.Value,
+			NameSpan: tok.Span,
+		}
+		if _, ok := lookupTypes[tok.Value]; !ok {
+			lookupTypeList := maps.Keys(lookupTypes)
+			slices.Sort(lookupTypeList)
+			finalError = &parseError{
+				source: p.source,
+				span:   tok.Span,
+				err:    fmt.Errorf("expected lookup flavor (one of %s), got %s", strings.Join(lookupTypeList, ", "), tok.Value),
+			}
+		}
+		tok, _ = p.next()
+	}
+
+	// Right table, either parenthesized or a bare table name:
+	var err error
+	if tok.Kind == TokenLParen {
+		op.Lparen = tok.Span
+		rightParser := p.split(TokenRParen)
+		op.Right, err = rightParser.tabularExpr()
+		finalError = joinErrors(finalError, makeErrorOpaque(err), rightParser.endSplit())
+		tok, _ = p.next()
+		if tok.Kind != TokenRParen {
+			return op, joinErrors(finalError, &parseError{
+				source: p.source,
+				span:   tok.Span,
+				err:    fmt.Errorf("expected ')', got %s", formatToken(p.source, tok)),
+			})
+		}
+		op.Rparen = tok.Span
+	} else {
+		p.prev()
+		tableName, err := p.qualifiedIdent()
+		if err != nil {
+			return op, joinErrors(finalError, makeErrorOpaque(err))
+		}
+		n := len(tableName.Parts)
+		op.Right = &TabularExpr{
+			Source: &TableRef{
+				Qualifiers: tableName.Parts[:n-1],
+				Table:      tableName.Parts[n-1],
+			},
+		}
+	}
+
+	// Conditions:
+	tok, _ = p.next()
+	if tok.Kind != TokenIdentifier || tok.Value != "on" {
+		return op, joinErrors(finalError, &parseError{
+			source: p.source,
+			span:   tok.Span,
+			err:    fmt.Errorf("expected 'on', got %s", formatToken(p.source, tok)),
+		})
+	}
+	op.On = tok.Span
+	op.Conditions, err = p.exprList()
+	finalError = joinErrors(finalError, makeErrorOpaque(err))
+
+	return op, finalError
+}
+
+func (p *parser) invokeOperator(pipe, keyword Token) (*InvokeOperator, error) {
+	op := &InvokeOperator{
+		Pipe:    pipe.Span,
+		Keyword: keyword.Span,
+	}
+	x, err := p.expr()
+	if err != nil {
+		return op, makeErrorOpaque(err)
+	}
+	call, ok := x.(*CallExpr)
+	if !ok {
+		return op, &parseError{
+			source: p.source,
+			span:   x.Span(),
+			err:    fmt.Errorf("expected function call after invoke"),
+		}
+	}
+	op.Call = call
+	return op, nil
+}
+
+func (p *parser) unionOperator(pipe, keyword Token) (*UnionOperator, error) {
+	op := &UnionOperator{
+		Pipe:    pipe.Span,
+		Keyword: keyword.Span,
+	}
+	var finalError error
+	for {
+		tok, _ := p.next()
+		if tok.Kind == TokenLParen {
+			tableParser := p.split(TokenRParen)
+			table, err := tableParser.tabularExpr()
+			if table != nil {
+				op.Tables = append(op.Tables, table)
+			}
+			finalError = joinErrors(finalError, makeErrorOpaque(err), tableParser.endSplit())
+			if tok, _ := p.next(); tok.Kind != TokenRParen {
+				return op, joinErrors(finalError, &parseError{
+					source: p.source,
+					span:   tok.Span,
+					err:    fmt.Errorf("expected ')', got %s", formatToken(p.source, tok)),
+				})
+			}
+		} else {
+			p.prev()
+			tableName, err := p.qualifiedIdent()
+			if err != nil {
+				return op, joinErrors(finalError, makeErrorOpaque(err))
+			}
+			n := len(tableName.Parts)
+			op.Tables = append(op.Tables, &TabularExpr{
+				Source: &TableRef{
+					Qualifiers: tableName.Parts[:n-1],
+					Table:      tableName.Parts[n-1],
+				},
+			})
+		}
+
+		if sep, _ := p.next(); sep.Kind != TokenComma {
+			p.prev()
+			return op, finalError
+		}
+	}
+}
+
 func (p *parser) asOperator(pipe, keyword Token) (*AsOperator, error) {
 	op := &AsOperator{
 		Pipe:    pipe.Span,
@@ -791,6 +1496,7 @@ func (p *parser) exprBinaryTrail(x Expr, minPrecedence int) (Expr, error) {
 		if !ok {
 			return x, finalError
 		}
+		op1.Kind = binaryOperatorKind(op1)
 		precedence1 := operatorPrecedence(op1.Kind)
 		if precedence1 < 0 || precedence1 < minPrecedence {
 			// Not a binary operator or below precedence threshold.
@@ -798,15 +1504,18 @@ func (p *parser) exprBinaryTrail(x Expr, minPrecedence int) (Expr, error) {
 			return x, finalError
 		}
 
-		if op1.Kind == TokenIn {
+		if isInOperator(op1.Kind) {
+			inExpr := &InExpr{
+				X:               x,
+				In:              op1.Span,
+				Lparen:          nullSpan(),
+				Rparen:          nullSpan(),
+				CaseInsensitive: op1.Kind == TokenCaseInsensitiveIn || op1.Kind == TokenCaseInsensitiveNotIn,
+				Negated:         op1.Kind == TokenNotIn || op1.Kind == TokenCaseInsensitiveNotIn,
+			}
+			x = inExpr
 			lparen, _ := p.next()
 			if lparen.Kind != TokenLParen {
-				x = &InExpr{
-					X:      x,
-					In:     op1.Span,
-					Lparen: nullSpan(),
-					Rparen: nullSpan(),
-				}
 				finalError = joinErrors(finalError, &parseError{
 					source: p.source,
 					span:   lparen.Span,
@@ -814,18 +1523,13 @@ func (p *parser) exprBinaryTrail(x Expr, minPrecedence int) (Expr, error) {
 				})
 				return x, finalError
 			}
+			inExpr.Lparen = lparen.Span
 			valParser := p.split(TokenRParen)
-			vals, err := valParser.exprList()
+			var err error
+			inExpr.Vals, err = valParser.exprList()
 			finalError = joinErrors(finalError, makeErrorOpaque(err), valParser.endSplit())
 			rparen, _ := p.next()
 			if rparen.Kind != TokenRParen {
-				x = &InExpr{
-					X:      x,
-					In:     op1.Span,
-					Lparen: lparen.Span,
-					Vals:   vals,
-					Rparen: nullSpan(),
-				}
 				finalError = joinErrors(finalError, &parseError{
 					source: p.source,
 					span:   lparen.Span,
@@ -833,14 +1537,7 @@ func (p *parser) exprBinaryTrail(x Expr, minPrecedence int) (Expr, error) {
 				})
 				return x, finalError
 			}
-
-			x = &InExpr{
-				X:      x,
-				In:     op1.Span,
-				Lparen: lparen.Span,
-				Vals:   vals,
-				Rparen: rparen.Span,
-			}
+			inExpr.Rparen = rparen.Span
 			continue
 		}
 
@@ -857,7 +1554,7 @@ func (p *parser) exprBinaryTrail(x Expr, minPrecedence int) (Expr, error) {
 			}
 			p.prev()
 
-			precedence2 := operatorPrecedence(op2.Kind)
+			precedence2 := operatorPrecedence(binaryOperatorKind(op2))
 			if precedence2 < 0 || precedence2 <= precedence1 {
 				// Not a binary operator or below the precedence of the original operator.
 				break
@@ -877,6 +1574,39 @@ func (p *parser) exprBinaryTrail(x Expr, minPrecedence int) (Expr, error) {
 	}
 }
 
+// stringOperators is the set of binary operators that are spelled as words.
+// They are not reserved, so they are scanned as identifiers
+// and only treated as operators after an operand.
+var stringOperators = map[string]TokenKind{
+	"contains":      TokenContains,
+	"contains_cs":   TokenContainsCS,
+	"endswith":      TokenEndsWith,
+	"endswith_cs":   TokenEndsWithCS,
+	"has":           TokenHas,
+	"has_cs":        TokenHasCS,
+	"startswith":    TokenStartsWith,
+	"startswith_cs": TokenStartsWithCS,
+}
+
+// binaryOperatorKind returns the operator kind of a token
+// that appears where a binary operator may occur.
+func binaryOperatorKind(tok Token) TokenKind {
+	if tok.Kind == TokenIdentifier {
+		if kind, ok := stringOperators[tok.Value]; ok {
+			return kind
+		}
+	}
+	return tok.Kind
+}
+
+// isInOperator reports whether the token kind is one of the "in" operator variants.
+func isInOperator(kind TokenKind) bool {
+	return kind == TokenIn ||
+		kind == TokenCaseInsensitiveIn ||
+		kind == TokenNotIn ||
+		kind == TokenCaseInsensitiveNotIn
+}
+
 func operatorPrecedence(op TokenKind) int {
 	switch op {
 	case TokenStar, TokenSlash, TokenMod:
@@ -884,7 +1614,11 @@ func operatorPrecedence(op TokenKind) int {
 	case TokenPlus, TokenMinus:
 		return 3
 	case TokenEq, TokenNE, TokenLT, TokenLE, TokenGT, TokenGE,
-		TokenCaseInsensitiveEq, TokenCaseInsensitiveNE, TokenIn:
+		TokenCaseInsensitiveEq, TokenCaseInsensitiveNE,
+		TokenIn, TokenCaseInsensitiveIn, TokenNotIn, TokenCaseInsensitiveNotIn,
+		TokenContains, TokenContainsCS, TokenHas, TokenHasCS,
+		TokenStartsWith, TokenStartsWithCS, TokenEndsWith, TokenEndsWithCS,
+		TokenMatchesRegex:
 		return 2
 	case TokenAnd:
 		return 1
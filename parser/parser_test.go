@@ -4,6 +4,7 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -52,6 +53,435 @@ var parserTests = []struct {
 			},
 		}},
 	},
+	{
+		name:  "QualifiedTableName",
+		query: "db.StormEvents",
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Qualifiers: []*Ident{
+					{
+						Name:     "db",
+						NameSpan: newSpan(0, 2),
+					},
+				},
+				Table: &Ident{
+					Name:     "StormEvents",
+					NameSpan: newSpan(3, 14),
+				},
+			},
+		}},
+	},
+	{
+		name:  "ThreePartTableName",
+		query: "project.`my dataset`.StormEvents",
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Qualifiers: []*Ident{
+					{
+						Name:     "project",
+						NameSpan: newSpan(0, 7),
+					},
+					{
+						Name:     "my dataset",
+						NameSpan: newSpan(8, 20),
+						Quoted:   true,
+					},
+				},
+				Table: &Ident{
+					Name:     "StormEvents",
+					NameSpan: newSpan(21, 32),
+				},
+			},
+		}},
+	},
+	{
+		name:  "TrailingDotTableName",
+		query: "db.",
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "db",
+					NameSpan: newSpan(0, 2),
+				},
+			},
+		}},
+		err: true,
+	},
+	{
+		name:  "Range",
+		query: "range i from 1 to 10 step 2 | take 3",
+		want: []Statement{&TabularExpr{
+			Source: &RangeSource{
+				Keyword: newSpan(0, 5),
+				Column: &Ident{
+					Name:     "i",
+					NameSpan: newSpan(6, 7),
+				},
+				From: newSpan(8, 12),
+				Start: &BasicLit{
+					Kind:      TokenNumber,
+					Value:     "1",
+					ValueSpan: newSpan(13, 14),
+				},
+				To: newSpan(15, 17),
+				Stop: &BasicLit{
+					Kind:      TokenNumber,
+					Value:     "10",
+					ValueSpan: newSpan(18, 20),
+				},
+				Step: newSpan(21, 25),
+				StepSize: &BasicLit{
+					Kind:      TokenNumber,
+					Value:     "2",
+					ValueSpan: newSpan(26, 27),
+				},
+			},
+			Operators: []TabularOperator{
+				&TakeOperator{
+					Pipe:    newSpan(28, 29),
+					Keyword: newSpan(30, 34),
+					RowCount: &BasicLit{
+						Kind:      TokenNumber,
+						Value:     "3",
+						ValueSpan: newSpan(35, 36),
+					},
+				},
+			},
+		}},
+	},
+	{
+		name:  "RangeTable",
+		query: "range | take 3",
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "range",
+					NameSpan: newSpan(0, 5),
+				},
+			},
+			Operators: []TabularOperator{
+				&TakeOperator{
+					Pipe:    newSpan(6, 7),
+					Keyword: newSpan(8, 12),
+					RowCount: &BasicLit{
+						Kind:      TokenNumber,
+						Value:     "3",
+						ValueSpan: newSpan(13, 14),
+					},
+				},
+			},
+		}},
+	},
+	{
+		name:  "RangeMissingStep",
+		query: "range i from 1 to 10",
+		want: []Statement{&TabularExpr{
+			Source: &RangeSource{
+				Keyword: newSpan(0, 5),
+				Column: &Ident{
+					Name:     "i",
+					NameSpan: newSpan(6, 7),
+				},
+				From: newSpan(8, 12),
+				Start: &BasicLit{
+					Kind:      TokenNumber,
+					Value:     "1",
+					ValueSpan: newSpan(13, 14),
+				},
+				To: newSpan(15, 17),
+				Stop: &BasicLit{
+					Kind:      TokenNumber,
+					Value:     "10",
+					ValueSpan: newSpan(18, 20),
+				},
+				Step: nullSpan(),
+			},
+		}},
+		err: true,
+	},
+	{
+		name:  "Print",
+		query: "print x = 1, 2",
+		want: []Statement{&TabularExpr{
+			Source: &PrintSource{
+				Keyword: newSpan(0, 5),
+				Cols: []*ExtendColumn{
+					{
+						Name: &Ident{
+							Name:     "x",
+							NameSpan: newSpan(6, 7),
+						},
+						Assign: newSpan(8, 9),
+						X: &BasicLit{
+							Kind:      TokenNumber,
+							Value:     "1",
+							ValueSpan: newSpan(10, 11),
+						},
+					},
+					{
+						Assign: nullSpan(),
+						X: &BasicLit{
+							Kind:      TokenNumber,
+							Value:     "2",
+							ValueSpan: newSpan(13, 14),
+						},
+					},
+				},
+			},
+		}},
+	},
+	{
+		name:  "PrintTable",
+		query: "print | count",
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "print",
+					NameSpan: newSpan(0, 5),
+				},
+			},
+			Operators: []TabularOperator{
+				&CountOperator{
+					Pipe:    newSpan(6, 7),
+					Keyword: newSpan(8, 13),
+					As:      nullSpan(),
+				},
+			},
+		}},
+	},
+	{
+		name:  "CountAs",
+		query: "X | count as Total",
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "X",
+					NameSpan: newSpan(0, 1),
+				},
+			},
+			Operators: []TabularOperator{
+				&CountOperator{
+					Pipe:    newSpan(2, 3),
+					Keyword: newSpan(4, 9),
+					As:      newSpan(10, 12),
+					Name: &Ident{
+						Name:     "Total",
+						NameSpan: newSpan(13, 18),
+					},
+				},
+			},
+		}},
+	},
+	{
+		name:  "Datatable",
+		query: `datatable(a:string, b:long) ["x", 1, "y", 2]`,
+		want: []Statement{&TabularExpr{
+			Source: &DatatableSource{
+				Keyword: newSpan(0, 9),
+				Lparen:  newSpan(9, 10),
+				Cols: []*DatatableColumn{
+					{
+						Name: &Ident{
+							Name:     "a",
+							NameSpan: newSpan(10, 11),
+						},
+						Colon: newSpan(11, 12),
+						Type: &Ident{
+							Name:     "string",
+							NameSpan: newSpan(12, 18),
+						},
+					},
+					{
+						Name: &Ident{
+							Name:     "b",
+							NameSpan: newSpan(20, 21),
+						},
+						Colon: newSpan(21, 22),
+						Type: &Ident{
+							Name:     "long",
+							NameSpan: newSpan(22, 26),
+						},
+					},
+				},
+				Rparen:   newSpan(26, 27),
+				Lbracket: newSpan(28, 29),
+				Values: []Expr{
+					&BasicLit{
+						Kind:      TokenString,
+						Value:     "x",
+						ValueSpan: newSpan(29, 32),
+					},
+					&BasicLit{
+						Kind:      TokenNumber,
+						Value:     "1",
+						ValueSpan: newSpan(34, 35),
+					},
+					&BasicLit{
+						Kind:      TokenString,
+						Value:     "y",
+						ValueSpan: newSpan(37, 40),
+					},
+					&BasicLit{
+						Kind:      TokenNumber,
+						Value:     "2",
+						ValueSpan: newSpan(42, 43),
+					},
+				},
+				Rbracket: newSpan(43, 44),
+			},
+		}},
+	},
+	{
+		name:  "ExternalData",
+		query: `externaldata (a:string) ["x.csv"] with (ignoreFirstRecord=true)`,
+		want: []Statement{&TabularExpr{
+			Source: &ExternalDataSource{
+				Keyword: newSpan(0, 12),
+				Lparen:  newSpan(13, 14),
+				Cols: []*DatatableColumn{
+					{
+						Name: &Ident{
+							Name:     "a",
+							NameSpan: newSpan(14, 15),
+						},
+						Colon: newSpan(15, 16),
+						Type: &Ident{
+							Name:     "string",
+							NameSpan: newSpan(16, 22),
+						},
+					},
+				},
+				Rparen:   newSpan(22, 23),
+				Lbracket: newSpan(24, 25),
+				URIs: []Expr{
+					&BasicLit{
+						Kind:      TokenString,
+						Value:     "x.csv",
+						ValueSpan: newSpan(25, 32),
+					},
+				},
+				Rbracket: newSpan(32, 33),
+				With:     newSpan(34, 38),
+				Wlparen:  newSpan(39, 40),
+				Properties: []*ExtendColumn{
+					{
+						Name: &Ident{
+							Name:     "ignoreFirstRecord",
+							NameSpan: newSpan(40, 57),
+						},
+						Assign: newSpan(57, 58),
+						X: (&Ident{
+							Name:     "true",
+							NameSpan: newSpan(58, 62),
+						}).AsQualified(),
+					},
+				},
+				Wrparen: newSpan(62, 63),
+			},
+		}},
+	},
+	{
+		name:  "ExternalDataNoProperties",
+		query: `externaldata (a:string) ["x.csv"] | take 1`,
+		want: []Statement{&TabularExpr{
+			Source: &ExternalDataSource{
+				Keyword: newSpan(0, 12),
+				Lparen:  newSpan(13, 14),
+				Cols: []*DatatableColumn{
+					{
+						Name: &Ident{
+							Name:     "a",
+							NameSpan: newSpan(14, 15),
+						},
+						Colon: newSpan(15, 16),
+						Type: &Ident{
+							Name:     "string",
+							NameSpan: newSpan(16, 22),
+						},
+					},
+				},
+				Rparen:   newSpan(22, 23),
+				Lbracket: newSpan(24, 25),
+				URIs: []Expr{
+					&BasicLit{
+						Kind:      TokenString,
+						Value:     "x.csv",
+						ValueSpan: newSpan(25, 32),
+					},
+				},
+				Rbracket: newSpan(32, 33),
+				With:     nullSpan(),
+				Wlparen:  nullSpan(),
+				Wrparen:  nullSpan(),
+			},
+			Operators: []TabularOperator{
+				&TakeOperator{
+					Pipe:    newSpan(34, 35),
+					Keyword: newSpan(36, 40),
+					RowCount: &BasicLit{
+						Kind:      TokenNumber,
+						Value:     "1",
+						ValueSpan: newSpan(41, 42),
+					},
+				},
+			},
+		}},
+	},
+	{
+		name:  "DatatableMismatchedValues",
+		query: `datatable(a:string, b:long) ["x", 1, "y"]`,
+		want: []Statement{&TabularExpr{
+			Source: &DatatableSource{
+				Keyword: newSpan(0, 9),
+				Lparen:  newSpan(9, 10),
+				Cols: []*DatatableColumn{
+					{
+						Name: &Ident{
+							Name:     "a",
+							NameSpan: newSpan(10, 11),
+						},
+						Colon: newSpan(11, 12),
+						Type: &Ident{
+							Name:     "string",
+							NameSpan: newSpan(12, 18),
+						},
+					},
+					{
+						Name: &Ident{
+							Name:     "b",
+							NameSpan: newSpan(20, 21),
+						},
+						Colon: newSpan(21, 22),
+						Type: &Ident{
+							Name:     "long",
+							NameSpan: newSpan(22, 26),
+						},
+					},
+				},
+				Rparen:   newSpan(26, 27),
+				Lbracket: newSpan(28, 29),
+				Values: []Expr{
+					&BasicLit{
+						Kind:      TokenString,
+						Value:     "x",
+						ValueSpan: newSpan(29, 32),
+					},
+					&BasicLit{
+						Kind:      TokenNumber,
+						Value:     "1",
+						ValueSpan: newSpan(34, 35),
+					},
+					&BasicLit{
+						Kind:      TokenString,
+						Value:     "y",
+						ValueSpan: newSpan(37, 40),
+					},
+				},
+				Rbracket: newSpan(40, 41),
+			},
+		}},
+		err: true,
+	},
 	{
 		name:  "PipeCount",
 		query: "StormEvents | count",
@@ -66,6 +496,43 @@ var parserTests = []struct {
 				&CountOperator{
 					Pipe:    newSpan(12, 13),
 					Keyword: newSpan(14, 19),
+					As:      nullSpan(),
+				},
+			},
+		}},
+	},
+	{
+		name:  "GetSchema",
+		query: "StormEvents | getschema",
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "StormEvents",
+					NameSpan: newSpan(0, 11),
+				},
+			},
+			Operators: []TabularOperator{
+				&GetSchemaOperator{
+					Pipe:    newSpan(12, 13),
+					Keyword: newSpan(14, 23),
+				},
+			},
+		}},
+	},
+	{
+		name:  "Serialize",
+		query: "StormEvents | serialize",
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "StormEvents",
+					NameSpan: newSpan(0, 11),
+				},
+			},
+			Operators: []TabularOperator{
+				&SerializeOperator{
+					Pipe:    newSpan(12, 13),
+					Keyword: newSpan(14, 23),
 				},
 			},
 		}},
@@ -84,10 +551,12 @@ var parserTests = []struct {
 				&CountOperator{
 					Pipe:    newSpan(12, 13),
 					Keyword: newSpan(14, 19),
+					As:      nullSpan(),
 				},
 				&CountOperator{
 					Pipe:    newSpan(20, 21),
 					Keyword: newSpan(22, 27),
+					As:      nullSpan(),
 				},
 			},
 		}},
@@ -356,6 +825,7 @@ var parserTests = []struct {
 				&CountOperator{
 					Pipe:    newSpan(12, 13),
 					Keyword: newSpan(14, 19),
+					As:      nullSpan(),
 				},
 				&WhereOperator{
 					Pipe:    newSpan(22, 23),
@@ -399,6 +869,127 @@ var parserTests = []struct {
 			},
 		}},
 	},
+	{
+		name:  "Contains",
+		query: `StormEvents | where State contains "flor"`,
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "StormEvents",
+					NameSpan: newSpan(0, 11),
+				},
+			},
+			Operators: []TabularOperator{
+				&WhereOperator{
+					Pipe:    newSpan(12, 13),
+					Keyword: newSpan(14, 19),
+					Predicate: &BinaryExpr{
+						X: (&Ident{
+							Name:     "State",
+							NameSpan: newSpan(20, 25),
+						}).AsQualified(),
+						OpSpan: newSpan(26, 34),
+						Op:     TokenContains,
+						Y: &BasicLit{
+							Kind:      TokenString,
+							ValueSpan: newSpan(35, 41),
+							Value:     "flor",
+						},
+					},
+				},
+			},
+		}},
+	},
+	{
+		name:  "StringOperatorNameAsColumn",
+		query: `T | project has`,
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "T",
+					NameSpan: newSpan(0, 1),
+				},
+			},
+			Operators: []TabularOperator{
+				&ProjectOperator{
+					Pipe:    newSpan(2, 3),
+					Keyword: newSpan(4, 11),
+					Cols: []*ProjectColumn{
+						{
+							Name: &Ident{
+								Name:     "has",
+								NameSpan: newSpan(12, 15),
+							},
+							Assign: nullSpan(),
+						},
+					},
+				},
+			},
+		}},
+	},
+	{
+		name:  "StringOperatorNameAsExtendColumn",
+		query: `T | extend startswith = 1`,
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "T",
+					NameSpan: newSpan(0, 1),
+				},
+			},
+			Operators: []TabularOperator{
+				&ExtendOperator{
+					Pipe:    newSpan(2, 3),
+					Keyword: newSpan(4, 10),
+					Cols: []*ExtendColumn{
+						{
+							Name: &Ident{
+								Name:     "startswith",
+								NameSpan: newSpan(11, 21),
+							},
+							Assign: newSpan(22, 23),
+							X: &BasicLit{
+								Kind:      TokenNumber,
+								Value:     "1",
+								ValueSpan: newSpan(24, 25),
+							},
+						},
+					},
+				},
+			},
+		}},
+	},
+	{
+		name:  "StringOperatorNameAsOperand",
+		query: `T | where has has "x"`,
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "T",
+					NameSpan: newSpan(0, 1),
+				},
+			},
+			Operators: []TabularOperator{
+				&WhereOperator{
+					Pipe:    newSpan(2, 3),
+					Keyword: newSpan(4, 9),
+					Predicate: &BinaryExpr{
+						X: (&Ident{
+							Name:     "has",
+							NameSpan: newSpan(10, 13),
+						}).AsQualified(),
+						OpSpan: newSpan(14, 17),
+						Op:     TokenHas,
+						Y: &BasicLit{
+							Kind:      TokenString,
+							ValueSpan: newSpan(18, 21),
+							Value:     "x",
+						},
+					},
+				},
+			},
+		}},
+	},
 	{
 		name:  "ComparisonWithSamePrecedenceLHS",
 		query: "foo | where x / y * z == 1",
@@ -595,6 +1186,76 @@ var parserTests = []struct {
 			},
 		}},
 	},
+	{
+		name:  "CaseInsensitiveIn",
+		query: `StormEvents | where State in~ ("georgia")`,
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "StormEvents",
+					NameSpan: newSpan(0, 11),
+				},
+			},
+			Operators: []TabularOperator{
+				&WhereOperator{
+					Pipe:    newSpan(12, 13),
+					Keyword: newSpan(14, 19),
+					Predicate: &InExpr{
+						X: (&Ident{
+							Name:     "State",
+							NameSpan: newSpan(20, 25),
+						}).AsQualified(),
+						In:     newSpan(26, 29),
+						Lparen: newSpan(30, 31),
+						Vals: []Expr{
+							&BasicLit{
+								Kind:      TokenString,
+								ValueSpan: newSpan(31, 40),
+								Value:     "georgia",
+							},
+						},
+						Rparen:          newSpan(40, 41),
+						CaseInsensitive: true,
+					},
+				},
+			},
+		}},
+	}, {
+		name:  "CaseInsensitiveNotIn",
+		query: `StormEvents | where State !in~ ("georgia")`,
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "StormEvents",
+					NameSpan: newSpan(0, 11),
+				},
+			},
+			Operators: []TabularOperator{
+				&WhereOperator{
+					Pipe:    newSpan(12, 13),
+					Keyword: newSpan(14, 19),
+					Predicate: &InExpr{
+						X: (&Ident{
+							Name:     "State",
+							NameSpan: newSpan(20, 25),
+						}).AsQualified(),
+						In:     newSpan(26, 30),
+						Lparen: newSpan(31, 32),
+						Vals: []Expr{
+							&BasicLit{
+								Kind:      TokenString,
+								ValueSpan: newSpan(32, 41),
+								Value:     "georgia",
+							},
+						},
+						Rparen:          newSpan(41, 42),
+						CaseInsensitive: true,
+						Negated:         true,
+					},
+				},
+			},
+		}},
+	},
 	{
 		name:  "InAnd",
 		query: `StormEvents | where State in ("GEORGIA", "MISSISSIPPI") and DamageProperty > 10000`,
@@ -988,33 +1649,202 @@ var parserTests = []struct {
 		}},
 	},
 	{
-		name:  "SortByNullsFirst",
-		query: "foo | sort by bar nulls first",
+		name:  "SortByNullsFirst",
+		query: "foo | sort by bar nulls first",
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "foo",
+					NameSpan: newSpan(0, 3),
+				},
+			},
+			Operators: []TabularOperator{
+				&SortOperator{
+					Pipe:    newSpan(4, 5),
+					Keyword: newSpan(6, 13),
+					Terms: []*SortTerm{
+						{
+							X: (&Ident{
+								Name:     "bar",
+								NameSpan: newSpan(14, 17),
+							}).AsQualified(),
+							AscDescSpan: nullSpan(),
+							NullsFirst:  true,
+							NullsSpan:   newSpan(18, 29),
+						},
+					},
+				},
+			},
+		}},
+	},
+	{
+		name:  "Sample",
+		query: "StormEvents | sample 5",
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "StormEvents",
+					NameSpan: newSpan(0, 11),
+				},
+			},
+			Operators: []TabularOperator{
+				&SampleOperator{
+					Pipe:    newSpan(12, 13),
+					Keyword: newSpan(14, 20),
+					RowCount: &BasicLit{
+						Kind:      TokenNumber,
+						Value:     "5",
+						ValueSpan: newSpan(21, 22),
+					},
+				},
+			},
+		}},
+	},
+	{
+		name:  "SampleFloat",
+		query: "StormEvents | sample 0.5",
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "StormEvents",
+					NameSpan: newSpan(0, 11),
+				},
+			},
+			Operators: []TabularOperator{
+				&SampleOperator{
+					Pipe:    newSpan(12, 13),
+					Keyword: newSpan(14, 20),
+					RowCount: &BasicLit{
+						Kind:      TokenNumber,
+						Value:     "0.5",
+						ValueSpan: newSpan(21, 24),
+					},
+				},
+			},
+		}},
+		err: true,
+	},
+	{
+		name:  "Skip",
+		query: "StormEvents | skip 5",
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "StormEvents",
+					NameSpan: newSpan(0, 11),
+				},
+			},
+			Operators: []TabularOperator{
+				&SkipOperator{
+					Pipe:    newSpan(12, 13),
+					Keyword: newSpan(14, 18),
+					RowCount: &BasicLit{
+						Kind:      TokenNumber,
+						Value:     "5",
+						ValueSpan: newSpan(19, 20),
+					},
+				},
+			},
+		}},
+	},
+	{
+		name:  "Offset",
+		query: "StormEvents | offset 5",
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "StormEvents",
+					NameSpan: newSpan(0, 11),
+				},
+			},
+			Operators: []TabularOperator{
+				&SkipOperator{
+					Pipe:    newSpan(12, 13),
+					Keyword: newSpan(14, 20),
+					RowCount: &BasicLit{
+						Kind:      TokenNumber,
+						Value:     "5",
+						ValueSpan: newSpan(21, 22),
+					},
+				},
+			},
+		}},
+	},
+	{
+		name:  "Search",
+		query: `StormEvents | search "flood"`,
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "StormEvents",
+					NameSpan: newSpan(0, 11),
+				},
+			},
+			Operators: []TabularOperator{
+				&SearchOperator{
+					Pipe:    newSpan(12, 13),
+					Keyword: newSpan(14, 20),
+					Colon:   nullSpan(),
+					Term: &BasicLit{
+						Kind:      TokenString,
+						Value:     "flood",
+						ValueSpan: newSpan(21, 28),
+					},
+				},
+			},
+		}},
+	},
+	{
+		name:  "SearchColumn",
+		query: `StormEvents | search State:"texas"`,
 		want: []Statement{&TabularExpr{
 			Source: &TableRef{
 				Table: &Ident{
-					Name:     "foo",
-					NameSpan: newSpan(0, 3),
+					Name:     "StormEvents",
+					NameSpan: newSpan(0, 11),
 				},
 			},
 			Operators: []TabularOperator{
-				&SortOperator{
-					Pipe:    newSpan(4, 5),
-					Keyword: newSpan(6, 13),
-					Terms: []*SortTerm{
-						{
-							X: (&Ident{
-								Name:     "bar",
-								NameSpan: newSpan(14, 17),
-							}).AsQualified(),
-							AscDescSpan: nullSpan(),
-							NullsFirst:  true,
-							NullsSpan:   newSpan(18, 29),
-						},
+				&SearchOperator{
+					Pipe:    newSpan(12, 13),
+					Keyword: newSpan(14, 20),
+					Column: &Ident{
+						Name:     "State",
+						NameSpan: newSpan(21, 26),
+					},
+					Colon: newSpan(26, 27),
+					Term: &BasicLit{
+						Kind:      TokenString,
+						Value:     "texas",
+						ValueSpan: newSpan(27, 34),
+					},
+				},
+			},
+		}},
+	},
+	{
+		name:  "SearchMissingColon",
+		query: `StormEvents | search State "texas"`,
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "StormEvents",
+					NameSpan: newSpan(0, 11),
+				},
+			},
+			Operators: []TabularOperator{
+				&SearchOperator{
+					Pipe:    newSpan(12, 13),
+					Keyword: newSpan(14, 20),
+					Column: &Ident{
+						Name:     "State",
+						NameSpan: newSpan(21, 26),
 					},
+					Colon: nullSpan(),
 				},
 			},
 		}},
+		err: true,
 	},
 	{
 		name:  "Take",
@@ -1062,6 +1892,29 @@ var parserTests = []struct {
 			},
 		}},
 	},
+	{
+		name:  "Head",
+		query: "StormEvents | head 5",
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "StormEvents",
+					NameSpan: newSpan(0, 11),
+				},
+			},
+			Operators: []TabularOperator{
+				&TakeOperator{
+					Pipe:    newSpan(12, 13),
+					Keyword: newSpan(14, 18),
+					RowCount: &BasicLit{
+						Kind:      TokenNumber,
+						Value:     "5",
+						ValueSpan: newSpan(19, 20),
+					},
+				},
+			},
+		}},
+	},
 	{
 		name:  "Project",
 		query: "StormEvents | project EventId, State, EventType",
@@ -1707,6 +2560,224 @@ var parserTests = []struct {
 			},
 		}},
 	},
+	{
+		name:  "Invoke",
+		query: "X | invoke f()",
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "X",
+					NameSpan: newSpan(0, 1),
+				},
+			},
+			Operators: []TabularOperator{
+				&InvokeOperator{
+					Pipe:    newSpan(2, 3),
+					Keyword: newSpan(4, 10),
+					Call: &CallExpr{
+						Func: &Ident{
+							Name:     "f",
+							NameSpan: newSpan(11, 12),
+						},
+						Lparen: newSpan(12, 13),
+						Rparen: newSpan(13, 14),
+					},
+				},
+			},
+		}},
+	},
+	{
+		name:  "InvokeNotCall",
+		query: "X | invoke f",
+		err:   true,
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "X",
+					NameSpan: newSpan(0, 1),
+				},
+			},
+			Operators: []TabularOperator{
+				&InvokeOperator{
+					Pipe:    newSpan(2, 3),
+					Keyword: newSpan(4, 10),
+				},
+			},
+		}},
+	},
+	{
+		name:  "Union",
+		query: "X | union Y, (Z | take 1)",
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "X",
+					NameSpan: newSpan(0, 1),
+				},
+			},
+			Operators: []TabularOperator{
+				&UnionOperator{
+					Pipe:    newSpan(2, 3),
+					Keyword: newSpan(4, 9),
+					Tables: []*TabularExpr{
+						{
+							Source: &TableRef{
+								Table: &Ident{
+									Name:     "Y",
+									NameSpan: newSpan(10, 11),
+								},
+							},
+						},
+						{
+							Source: &TableRef{
+								Table: &Ident{
+									Name:     "Z",
+									NameSpan: newSpan(14, 15),
+								},
+							},
+							Operators: []TabularOperator{
+								&TakeOperator{
+									Pipe:    newSpan(16, 17),
+									Keyword: newSpan(18, 22),
+									RowCount: &BasicLit{
+										Kind:      TokenNumber,
+										Value:     "1",
+										ValueSpan: newSpan(23, 24),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}},
+	},
+	{
+		name:  "Lookup",
+		query: "X | lookup Y on Key",
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "X",
+					NameSpan: newSpan(0, 1),
+				},
+			},
+			Operators: []TabularOperator{
+				&LookupOperator{
+					Pipe:    newSpan(2, 3),
+					Keyword: newSpan(4, 10),
+
+					Kind:       nullSpan(),
+					KindAssign: nullSpan(),
+
+					Lparen: nullSpan(),
+					Right: &TabularExpr{
+						Source: &TableRef{
+							Table: &Ident{
+								Name:     "Y",
+								NameSpan: newSpan(11, 12),
+							},
+						},
+					},
+					Rparen: nullSpan(),
+					On:     newSpan(13, 15),
+					Conditions: []Expr{
+						(&Ident{
+							Name:     "Key",
+							NameSpan: newSpan(16, 19),
+						}).AsQualified(),
+					},
+				},
+			},
+		}},
+	},
+	{
+		name:  "LookupInner",
+		query: "X | lookup kind=inner (Y) on Key",
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "X",
+					NameSpan: newSpan(0, 1),
+				},
+			},
+			Operators: []TabularOperator{
+				&LookupOperator{
+					Pipe:    newSpan(2, 3),
+					Keyword: newSpan(4, 10),
+
+					Kind:       newSpan(11, 15),
+					KindAssign: newSpan(15, 16),
+					Flavor: &Ident{
+						Name:     "inner",
+						NameSpan: newSpan(16, 21),
+					},
+
+					Lparen: newSpan(22, 23),
+					Right: &TabularExpr{
+						Source: &TableRef{
+							Table: &Ident{
+								Name:     "Y",
+								NameSpan: newSpan(23, 24),
+							},
+						},
+					},
+					Rparen: newSpan(24, 25),
+					On:     newSpan(26, 28),
+					Conditions: []Expr{
+						(&Ident{
+							Name:     "Key",
+							NameSpan: newSpan(29, 32),
+						}).AsQualified(),
+					},
+				},
+			},
+		}},
+	},
+	{
+		name:  "LookupBadFlavor",
+		query: "X | lookup kind=innerunique Y on Key",
+		err:   true,
+		want: []Statement{&TabularExpr{
+			Source: &TableRef{
+				Table: &Ident{
+					Name:     "X",
+					NameSpan: newSpan(0, 1),
+				},
+			},
+			Operators: []TabularOperator{
+				&LookupOperator{
+					Pipe:    newSpan(2, 3),
+					Keyword: newSpan(4, 10),
+
+					Kind:       newSpan(11, 15),
+					KindAssign: newSpan(15, 16),
+					Flavor: &Ident{
+						Name:     "innerunique",
+						NameSpan: newSpan(16, 27),
+					},
+
+					Lparen: nullSpan(),
+					Right: &TabularExpr{
+						Source: &TableRef{
+							Table: &Ident{
+								Name:     "Y",
+								NameSpan: newSpan(28, 29),
+							},
+						},
+					},
+					Rparen: nullSpan(),
+					On:     newSpan(30, 32),
+					Conditions: []Expr{
+						(&Ident{
+							Name:     "Key",
+							NameSpan: newSpan(33, 36),
+						}).AsQualified(),
+					},
+				},
+			},
+		}},
+	},
 	{
 		name:  "JoinComplexRight",
 		query: "X | join (Y | where z == 5) on Key",
@@ -1867,6 +2938,7 @@ var parserTests = []struct {
 				&CountOperator{
 					Pipe:    newSpan(20, 21),
 					Keyword: newSpan(22, 27),
+					As:      nullSpan(),
 				},
 			},
 		}},
@@ -1959,6 +3031,123 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestDatatableValueCountError(t *testing.T) {
+	const query = `datatable(a:string, b:long, c:bool) ["x", 1, true, "y"]`
+	const want = "1:37: datatable: 4 values is not a multiple of 3 columns"
+	_, err := Parse(query)
+	if err == nil {
+		t.Fatalf("Parse(%q) did not return an error", query)
+	}
+	if got := err.Error(); !strings.HasSuffix(got, want) {
+		t.Errorf("Parse(%q) error = %q; want suffix %q", query, got, want)
+	}
+}
+
+func TestParseOperators(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []TabularOperator
+		err    bool
+	}{
+		{
+			name:   "Single",
+			source: "take 5",
+			want: []TabularOperator{
+				&TakeOperator{
+					Pipe:    newSpan(0, 0),
+					Keyword: newSpan(0, 4),
+					RowCount: &BasicLit{
+						Kind:      TokenNumber,
+						Value:     "5",
+						ValueSpan: newSpan(5, 6),
+					},
+				},
+			},
+		},
+		{
+			name:   "Multiple",
+			source: "where x | take 5",
+			want: []TabularOperator{
+				&WhereOperator{
+					Pipe:    newSpan(0, 0),
+					Keyword: newSpan(0, 5),
+					Predicate: &QualifiedIdent{
+						Parts: []*Ident{{
+							Name:     "x",
+							NameSpan: newSpan(6, 7),
+						}},
+					},
+				},
+				&TakeOperator{
+					Pipe:    newSpan(8, 9),
+					Keyword: newSpan(10, 14),
+					RowCount: &BasicLit{
+						Kind:      TokenNumber,
+						Value:     "5",
+						ValueSpan: newSpan(15, 16),
+					},
+				},
+			},
+		},
+		{
+			name:   "Empty",
+			source: "",
+			err:    true,
+		},
+		{
+			name:   "DataSource",
+			source: "T | take 5",
+			err:    true,
+		},
+		{
+			name:   "LeadingPipe",
+			source: "| take 5",
+			err:    true,
+		},
+		{
+			name:   "TrailingStatement",
+			source: "take 5; T",
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseOperators(test.source)
+			if err != nil {
+				if !test.err {
+					t.Fatalf("ParseOperators(%q) returned unexpected error: %v", test.source, err)
+				}
+				t.Logf("ParseOperators(%q) error (as expected): %v", test.source, err)
+				return
+			}
+			if test.err {
+				t.Fatalf("ParseOperators(%q) did not return an error", test.source)
+			}
+			if diff := cmp.Diff(test.want, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("ParseOperators(%q) (-want +got):\n%s", test.source, diff)
+			}
+		})
+	}
+}
+
+func TestRespan(t *testing.T) {
+	const query = "T | where f(x) > 1 and y in (1, 2) | join kind=inner (U | extend z = -a[0]) on b | sort by c desc"
+	stmts, err := Parse(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	span := newSpan(100, 102)
+	Respan(stmts[0], span)
+	Walk(stmts[0], func(n Node) bool {
+		if got := n.Span(); got != span {
+			t.Errorf("%T.Span() = %v; want %v", n, got, span)
+		}
+		return true
+	})
+}
+
 func FuzzParse(f *testing.F) {
 	for _, test := range parserTests {
 		f.Add(test.query)
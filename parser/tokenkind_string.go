@@ -36,25 +36,38 @@ func _() {
 	_ = x[TokenLBracket-26]
 	_ = x[TokenRBracket-27]
 	_ = x[TokenIn-28]
-	_ = x[TokenBy-29]
-	_ = x[TokenSemi-30]
+	_ = x[TokenCaseInsensitiveIn-29]
+	_ = x[TokenNotIn-30]
+	_ = x[TokenCaseInsensitiveNotIn-31]
+	_ = x[TokenContains-32]
+	_ = x[TokenContainsCS-33]
+	_ = x[TokenHas-34]
+	_ = x[TokenHasCS-35]
+	_ = x[TokenStartsWith-36]
+	_ = x[TokenStartsWithCS-37]
+	_ = x[TokenEndsWith-38]
+	_ = x[TokenEndsWithCS-39]
+	_ = x[TokenMatchesRegex-40]
+	_ = x[TokenBy-41]
+	_ = x[TokenSemi-42]
+	_ = x[TokenColon-43]
 	_ = x[TokenError - -1]
 }
 
 const (
 	_TokenKind_name_0 = "TokenError"
-	_TokenKind_name_1 = "TokenIdentifierTokenQuotedIdentifierTokenNumberTokenStringTokenAndTokenOrTokenPipeTokenDotTokenCommaTokenPlusTokenMinusTokenStarTokenSlashTokenModTokenAssignTokenEqTokenNETokenLTTokenLETokenGTTokenGETokenCaseInsensitiveEqTokenCaseInsensitiveNETokenLParenTokenRParenTokenLBracketTokenRBracketTokenInTokenByTokenSemi"
+	_TokenKind_name_1 = "TokenIdentifierTokenQuotedIdentifierTokenNumberTokenStringTokenAndTokenOrTokenPipeTokenDotTokenCommaTokenPlusTokenMinusTokenStarTokenSlashTokenModTokenAssignTokenEqTokenNETokenLTTokenLETokenGTTokenGETokenCaseInsensitiveEqTokenCaseInsensitiveNETokenLParenTokenRParenTokenLBracketTokenRBracketTokenInTokenCaseInsensitiveInTokenNotInTokenCaseInsensitiveNotInTokenContainsTokenContainsCSTokenHasTokenHasCSTokenStartsWithTokenStartsWithCSTokenEndsWithTokenEndsWithCSTokenMatchesRegexTokenByTokenSemiTokenColon"
 )
 
 var (
-	_TokenKind_index_1 = [...]uint16{0, 15, 36, 47, 58, 66, 73, 82, 90, 100, 109, 119, 128, 138, 146, 157, 164, 171, 178, 185, 192, 199, 221, 243, 254, 265, 278, 291, 298, 305, 314}
+	_TokenKind_index_1 = [...]uint16{0, 15, 36, 47, 58, 66, 73, 82, 90, 100, 109, 119, 128, 138, 146, 157, 164, 171, 178, 185, 192, 199, 221, 243, 254, 265, 278, 291, 298, 320, 330, 355, 368, 383, 391, 401, 416, 433, 446, 461, 478, 485, 494, 504}
 )
 
 func (i TokenKind) String() string {
 	switch {
 	case i == -1:
 		return _TokenKind_name_0
-	case 1 <= i && i <= 30:
+	case 1 <= i && i <= 43:
 		i -= 1
 		return _TokenKind_name_1[_TokenKind_index_1[i]:_TokenKind_index_1[i+1]]
 	default:
@@ -0,0 +1,212 @@
+// Copyright 2024 RunReveal Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package pql
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/runreveal/pql/parser"
+)
+
+// Policy is a set of restrictions on the constructs
+// that are permitted in a Pipeline Query Language statement.
+// nil or the zero value permits everything.
+type Policy struct {
+	// Operators is the list of tabular operator names that are permitted
+	// (e.g. "where", "project").
+	// Aliases are permitted if their canonical name is listed
+	// (e.g. "sort" permits "order").
+	// If Operators is nil, all operators are permitted.
+	Operators []string
+	// Functions is the list of function names that are permitted.
+	// This includes functions that would otherwise be passed through
+	// to the underlying SQL engine.
+	// If Functions is nil, all functions are permitted.
+	Functions []string
+	// If MaxTake is positive, then take and top operators
+	// must use a literal row count no greater than MaxTake.
+	MaxTake int64
+	// If MaxRangeRows is positive, then range data sources
+	// must use literal bounds and step sizes
+	// that produce no more than MaxRangeRows rows.
+	MaxRangeRows int64
+}
+
+// check returns an error if any of the statements use a construct
+// that is not permitted by the policy.
+func (policy *Policy) check(source string, stmts []parser.Statement) error {
+	if policy == nil {
+		return nil
+	}
+	var err error
+	for _, stmt := range stmts {
+		parser.Walk(stmt, func(n parser.Node) bool {
+			if err != nil {
+				return false
+			}
+			switch n := n.(type) {
+			case *parser.InvokeOperator:
+				// The call names a tabular function, not a scalar function,
+				// so it is not subject to the Functions list.
+				// Tabular functions are expanded before the rest of the check.
+				err = policy.checkOperator(source, n)
+				return false
+			case parser.TabularOperator:
+				err = policy.checkOperator(source, n)
+			case *parser.RangeSource:
+				err = policy.checkRange(source, n)
+			case *parser.CallExpr:
+				if policy.Functions != nil && !slices.Contains(policy.Functions, n.Func.Name) {
+					err = &compileError{
+						source: source,
+						span:   n.Func.NameSpan,
+						err:    fmt.Errorf("function %s not permitted", n.Func.Name),
+					}
+				}
+			}
+			return err == nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkInvokes returns an error if any of the statements
+// use an invoke operator that is not permitted by the policy.
+// It is run before tabular functions are expanded,
+// since the expanded statements no longer contain invoke operators.
+func (policy *Policy) checkInvokes(source string, stmts []parser.Statement) error {
+	if policy == nil {
+		return nil
+	}
+	var err error
+	for _, stmt := range stmts {
+		parser.Walk(stmt, func(n parser.Node) bool {
+			if op, ok := n.(*parser.InvokeOperator); ok && err == nil {
+				err = policy.checkOperator(source, op)
+			}
+			return err == nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (policy *Policy) checkOperator(source string, op parser.TabularOperator) error {
+	name := operatorName(op)
+	if policy.Operators != nil && !slices.Contains(policy.Operators, name) {
+		return &compileError{
+			source: source,
+			span:   op.Span(),
+			err:    fmt.Errorf("%s operator not permitted", name),
+		}
+	}
+	if policy.MaxTake <= 0 {
+		return nil
+	}
+	var rowCount parser.Expr
+	switch op := op.(type) {
+	case *parser.TakeOperator:
+		rowCount = op.RowCount
+	case *parser.TopOperator:
+		rowCount = op.RowCount
+	default:
+		return nil
+	}
+	lit, ok := rowCount.(*parser.BasicLit)
+	if !ok || !lit.IsInteger() {
+		return &compileError{
+			source: source,
+			span:   rowCount.Span(),
+			err:    fmt.Errorf("%s row count must be an integer literal", name),
+		}
+	}
+	if n, err := strconv.ParseInt(lit.Value, 10, 64); err != nil || n > policy.MaxTake {
+		return &compileError{
+			source: source,
+			span:   lit.Span(),
+			err:    fmt.Errorf("%s row count %s exceeds maximum of %d", name, lit.Value, policy.MaxTake),
+		}
+	}
+	return nil
+}
+
+func (policy *Policy) checkRange(source string, src *parser.RangeSource) error {
+	if policy.MaxRangeRows <= 0 {
+		return nil
+	}
+	start, startOK := numberLiteralValue(src.Start)
+	stop, stopOK := numberLiteralValue(src.Stop)
+	step, stepOK := numberLiteralValue(src.StepSize)
+	if !startOK || !stopOK || !stepOK {
+		return &compileError{
+			source: source,
+			span:   src.Span(),
+			err:    fmt.Errorf("range bounds and step must be number literals"),
+		}
+	}
+	if step == 0 {
+		// Reported during compilation.
+		return nil
+	}
+	if n := math.Floor((stop-start)/step) + 1; n > float64(policy.MaxRangeRows) {
+		return &compileError{
+			source: source,
+			span:   src.Span(),
+			err:    fmt.Errorf("range produces more than the maximum of %d rows", policy.MaxRangeRows),
+		}
+	}
+	return nil
+}
+
+// operatorName returns the canonical name of the given tabular operator.
+func operatorName(op parser.TabularOperator) string {
+	switch op.(type) {
+	case *parser.AsOperator:
+		return "as"
+	case *parser.CountOperator:
+		return "count"
+	case *parser.ExtendOperator:
+		return "extend"
+	case *parser.GetSchemaOperator:
+		return "getschema"
+	case *parser.InvokeOperator:
+		return "invoke"
+	case *parser.JoinOperator:
+		return "join"
+	case *parser.LookupOperator:
+		return "lookup"
+	case *parser.ProjectOperator:
+		return "project"
+	case *parser.SampleOperator:
+		return "sample"
+	case *parser.SearchOperator:
+		return "search"
+	case *parser.SerializeOperator:
+		return "serialize"
+	case *parser.SkipOperator:
+		return "skip"
+	case *parser.SortOperator:
+		return "sort"
+	case *parser.SummarizeOperator:
+		return "summarize"
+	case *parser.TakeOperator:
+		return "take"
+	case *parser.TopOperator:
+		return "top"
+	case *parser.UnionOperator:
+		return "union"
+	case *parser.WhereOperator:
+		return "where"
+	default:
+		return fmt.Sprintf("%T", op)
+	}
+}
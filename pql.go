@@ -6,6 +6,9 @@ package pql
 
 import (
 	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -27,6 +30,33 @@ type CompileOptions struct {
 	// For example, a "foo": "$1" entry would replace unquoted "foo" identifiers
 	// with "$1" in the resulting SQL.
 	Parameters map[string]string
+
+	// Allow restricts the constructs that may appear in the statement.
+	// If nil, all constructs are permitted.
+	Allow *Policy
+
+	// Rules is a list of rules to apply to the tabular expression
+	// before it is translated into SQL.
+	// See [Rewrite] for details.
+	// The rules are also applied to each let statement's expression
+	// as if it were written as "print name = expr",
+	// so that errors they return for it are reported.
+	// Changes they make to let statements are discarded.
+	Rules []RewriteRule
+
+	// TabularFunctions is a map of function names
+	// to Pipeline Query Language fragments
+	// that can be called with the invoke operator.
+	// A fragment is a sequence of tabular operators separated by pipes
+	// without a leading pipe or data source
+	// (e.g. "where Country == 'US' | extend Region = 'NA'").
+	// "T | invoke f()" is translated as if the fragment's operators
+	// were written in its place.
+	// The Allow policy applies to the fragment's operators,
+	// and errors in them are reported at the position of the invoke operator.
+	// Syntax errors in a fragment name the function
+	// and give positions relative to the fragment.
+	TabularFunctions map[string]string
 }
 
 // Compile converts the given Pipeline Query Language statement
@@ -36,6 +66,20 @@ func (opts *CompileOptions) Compile(source string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if opts != nil {
+		if err := opts.Allow.checkInvokes(source, stmts); err != nil {
+			return "", err
+		}
+	}
+	stmts, err = opts.expandInvokes(source, stmts)
+	if err != nil {
+		return "", err
+	}
+	if opts != nil {
+		if err := opts.Allow.check(source, stmts); err != nil {
+			return "", err
+		}
+	}
 	var expr *parser.TabularExpr
 	scope := make(map[string]string)
 	if opts != nil {
@@ -43,6 +87,8 @@ func (opts *CompileOptions) Compile(source string) (string, error) {
 			scope[k] = v
 		}
 	}
+	letNames := make(map[string]struct{})
+	referencedTables := make(map[string]struct{})
 	for _, stmt := range stmts {
 		switch stmt := stmt.(type) {
 		case *parser.TabularExpr:
@@ -60,6 +106,31 @@ func (opts *CompileOptions) Compile(source string) (string, error) {
 				// they should not be in scope.
 				continue
 			}
+			if _, redefined := letNames[stmt.Name.Name]; redefined {
+				return "", &compileError{
+					source: source,
+					span:   stmt.Name.NameSpan,
+					err:    fmt.Errorf("%s redefined", stmt.Name.Name),
+				}
+			}
+			letNames[stmt.Name.Name] = struct{}{}
+			if opts != nil && len(opts.Rules) > 0 {
+				// Let expressions are substituted into the query,
+				// so the rules must also be able to reject them.
+				letExpr := &parser.TabularExpr{
+					Source: &parser.PrintSource{
+						Keyword: stmt.Keyword,
+						Cols: []*parser.ExtendColumn{{
+							Name:   stmt.Name,
+							Assign: stmt.Assign,
+							X:      stmt.X,
+						}},
+					},
+				}
+				if _, _, err := Rewrite(letExpr, opts.Rules...); err != nil {
+					return "", err
+				}
+			}
 			ctx := &exprContext{
 				source: source,
 				scope:  scope,
@@ -70,6 +141,7 @@ func (opts *CompileOptions) Compile(source string) (string, error) {
 				return "", err
 			}
 			scope[stmt.Name.Name] = sb.String()
+			recordInTableReferences(referencedTables, stmt.X)
 		default:
 			return "", &compileError{
 				source: source,
@@ -81,8 +153,21 @@ func (opts *CompileOptions) Compile(source string) (string, error) {
 	if expr == nil {
 		return "", fmt.Errorf("missing tabular queries")
 	}
+	if opts != nil && len(opts.Rules) > 0 {
+		var err error
+		expr, _, err = Rewrite(expr, opts.Rules...)
+		if err != nil {
+			return "", err
+		}
+	}
 
-	subqueries, err := splitQueries(nil, source, expr)
+	ctx := &exprContext{
+		source:           source,
+		scope:            scope,
+		nestedQueries:    new([]nestedQuery),
+		referencedTables: referencedTables,
+	}
+	subqueries, err := splitQueries(nil, ctx, expr)
 	if err != nil {
 		return "", err
 	}
@@ -90,10 +175,6 @@ func (opts *CompileOptions) Compile(source string) (string, error) {
 	sb := new(strings.Builder)
 	ctes := subqueries[:len(subqueries)-1]
 	query := subqueries[len(subqueries)-1]
-	ctx := &exprContext{
-		source: source,
-		scope:  scope,
-	}
 	if len(ctes) > 0 {
 		sb.WriteString("WITH ")
 		for i, sub := range ctes {
@@ -117,60 +198,232 @@ func (opts *CompileOptions) Compile(source string) (string, error) {
 	return sb.String(), nil
 }
 
+// maxInvokeDepth is the maximum nesting depth of invoke operators.
+// It guards against tabular functions that invoke themselves.
+const maxInvokeDepth = 100
+
+// expandInvokes replaces each invoke operator in the statements
+// with the operators of the tabular function it calls.
+func (opts *CompileOptions) expandInvokes(source string, stmts []parser.Statement) ([]parser.Statement, error) {
+	var newStmts []parser.Statement
+	for i, stmt := range stmts {
+		expr, ok := stmt.(*parser.TabularExpr)
+		if !ok {
+			continue
+		}
+		newExpr, err := opts.expandTabularInvokes(source, expr, 0)
+		if err != nil {
+			return nil, err
+		}
+		if newExpr != expr {
+			if newStmts == nil {
+				newStmts = slices.Clone(stmts)
+			}
+			newStmts[i] = newExpr
+		}
+	}
+	if newStmts == nil {
+		return stmts, nil
+	}
+	return newStmts, nil
+}
+
+func (opts *CompileOptions) expandTabularInvokes(source string, expr *parser.TabularExpr, depth int) (*parser.TabularExpr, error) {
+	newExpr, _, err := mapTabularExprs(expr, func(expr *parser.TabularExpr) (*parser.TabularExpr, []string, error) {
+		var newOperators []parser.TabularOperator
+		for i, op := range expr.Operators {
+			invoke, ok := op.(*parser.InvokeOperator)
+			if !ok {
+				if newOperators != nil {
+					newOperators = append(newOperators, op)
+				}
+				continue
+			}
+			if newOperators == nil {
+				newOperators = slices.Clone(expr.Operators[:i])
+			}
+			ops, err := opts.invokeOperators(source, invoke, depth)
+			if err != nil {
+				return nil, nil, err
+			}
+			newOperators = append(newOperators, ops...)
+		}
+		if newOperators == nil {
+			return expr, nil, nil
+		}
+		newExpr := *expr
+		newExpr.Operators = newOperators
+		return &newExpr, nil, nil
+	})
+	return newExpr, err
+}
+
+// invokeOperators returns the operators of the tabular function
+// called by the given invoke operator,
+// with any invoke operators inside the function expanded.
+// The returned nodes take on the position of the invoke operator,
+// so errors in the function's operators are reported at the call.
+func (opts *CompileOptions) invokeOperators(source string, invoke *parser.InvokeOperator, depth int) ([]parser.TabularOperator, error) {
+	name := invoke.Call.Func.Name
+	var body string
+	ok := false
+	if opts != nil {
+		body, ok = opts.TabularFunctions[name]
+	}
+	if !ok {
+		return nil, &compileError{
+			source: source,
+			span:   invoke.Call.Func.Span(),
+			err:    fmt.Errorf("unknown tabular function %s", name),
+		}
+	}
+	if len(invoke.Call.Args) > 0 {
+		return nil, &compileError{
+			source: source,
+			span:   invoke.Call.Span(),
+			err:    fmt.Errorf("%s() takes no arguments (got %d)", name, len(invoke.Call.Args)),
+		}
+	}
+	if depth >= maxInvokeDepth {
+		return nil, &compileError{
+			source: source,
+			span:   invoke.Span(),
+			err:    fmt.Errorf("too many nested invoke operators (is %s recursive?)", name),
+		}
+	}
+
+	// Positions in parse errors are relative to the function's body.
+	ops, err := parser.ParseOperators(body)
+	if err != nil {
+		return nil, &compileError{
+			source: source,
+			span:   invoke.Call.Func.Span(),
+			err:    fmt.Errorf("tabular function %s: %w", name, err),
+		}
+	}
+	for _, op := range ops {
+		nameUnnamedColumns(body, op)
+		parser.Respan(op, invoke.Span())
+	}
+	fn, err := opts.expandTabularInvokes(source, &parser.TabularExpr{Operators: ops}, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	return fn.Operators, nil
+}
+
+// nameUnnamedColumns gives each extend or summarize column in n
+// that does not have a name the name it would be given from source,
+// so that it does not depend on the column's position.
+func nameUnnamedColumns(source string, n parser.Node) {
+	parser.Walk(n, func(n parser.Node) bool {
+		var name **parser.Ident
+		var x parser.Expr
+		switch n := n.(type) {
+		case *parser.ExtendColumn:
+			name, x = &n.Name, n.X
+		case *parser.SummarizeColumn:
+			name, x = &n.Name, n.X
+		default:
+			return true
+		}
+		if *name == nil && x != nil {
+			span := x.Span()
+			*name = &parser.Ident{
+				Name:     source[span.Start:span.End],
+				NameSpan: nullSpan(),
+			}
+		}
+		return true
+	})
+}
+
 type subquery struct {
 	name      string
 	sourceSQL string
 
 	op   parser.TabularOperator
 	sort *parser.SortOperator
+	skip *parser.SkipOperator
 	take *parser.TakeOperator
+
+	// serialized is true if the subquery's input rows have a well-defined order
+	// because of a preceding serialize operator.
+	serialized bool
 }
 
 // splitQueries appends queries to dst that represent the given tabular expression.
 // The last element of the returned slice will be the query that represents the full expression.
-func splitQueries(dst []*subquery, source string, expr *parser.TabularExpr) ([]*subquery, error) {
+func splitQueries(dst []*subquery, ctx *exprContext, expr *parser.TabularExpr) ([]*subquery, error) {
 	dstStart := len(dst)
 	var lastSubquery *subquery
+	serialized := false
 	for i := 0; i < len(expr.Operators); i++ {
+		if ctx.referencedTables != nil {
+			recordInTableReferences(ctx.referencedTables, expr.Operators[i])
+		}
 		switch op := expr.Operators[i].(type) {
 		case *parser.AsOperator:
 			var err error
-			lastSubquery, err = chainSubquery(dst, dstStart, expr.Source)
+			lastSubquery, err = chainSubquery(ctx, dst, dstStart, expr.Source)
 			if err != nil {
 				return nil, err
 			}
+			if _, referenced := ctx.referencedTables[op.Name.Name]; referenced {
+				// The subquery would capture earlier reads of the table,
+				// possibly including its own input.
+				return nil, &compileError{
+					source: ctx.source,
+					span:   op.Name.Span(),
+					err:    fmt.Errorf("as %s: name is already used by a table earlier in the query", op.Name.Name),
+				}
+			}
 			lastSubquery.name = op.Name.Name
 			// AsOperator gets treated basically the same as nil,
 			// but won't permit anything to be attached.
 			lastSubquery.op = op
 			dst = append(dst, lastSubquery)
 		case *parser.SortOperator:
-			if lastSubquery == nil || !canAttachSort(lastSubquery.op) || lastSubquery.sort != nil || lastSubquery.take != nil {
+			if lastSubquery == nil || !canAttachSort(lastSubquery.op) || lastSubquery.sort != nil || lastSubquery.skip != nil || lastSubquery.take != nil {
 				var err error
-				lastSubquery, err = chainSubquery(dst, dstStart, expr.Source)
+				lastSubquery, err = chainSubquery(ctx, dst, dstStart, expr.Source)
 				if err != nil {
 					return nil, err
 				}
+				lastSubquery.serialized = serialized
 				dst = append(dst, lastSubquery)
 			}
 			lastSubquery.sort = op
+		case *parser.SkipOperator:
+			if lastSubquery == nil || !canAttachSort(lastSubquery.op) || lastSubquery.skip != nil || lastSubquery.take != nil {
+				var err error
+				lastSubquery, err = chainSubquery(ctx, dst, dstStart, expr.Source)
+				if err != nil {
+					return nil, err
+				}
+				lastSubquery.serialized = serialized
+				dst = append(dst, lastSubquery)
+			}
+			lastSubquery.skip = op
 		case *parser.TakeOperator:
 			if lastSubquery == nil || !canAttachSort(lastSubquery.op) || lastSubquery.take != nil {
 				var err error
-				lastSubquery, err = chainSubquery(dst, dstStart, expr.Source)
+				lastSubquery, err = chainSubquery(ctx, dst, dstStart, expr.Source)
 				if err != nil {
 					return nil, err
 				}
+				lastSubquery.serialized = serialized
 				dst = append(dst, lastSubquery)
 			}
 			lastSubquery.take = op
 		case *parser.TopOperator:
-			if lastSubquery == nil || !canAttachSort(lastSubquery.op) || lastSubquery.sort != nil || lastSubquery.take != nil {
+			if lastSubquery == nil || !canAttachSort(lastSubquery.op) || lastSubquery.sort != nil || lastSubquery.skip != nil || lastSubquery.take != nil {
 				var err error
-				lastSubquery, err = chainSubquery(dst, dstStart, expr.Source)
+				lastSubquery, err = chainSubquery(ctx, dst, dstStart, expr.Source)
 				if err != nil {
 					return nil, err
 				}
+				lastSubquery.serialized = serialized
 				dst = append(dst, lastSubquery)
 			}
 			lastSubquery.sort = &parser.SortOperator{
@@ -184,53 +437,33 @@ func splitQueries(dst []*subquery, source string, expr *parser.TabularExpr) ([]*
 				RowCount: op.RowCount,
 			}
 		case *parser.JoinOperator:
-			leftSubquery := len(dst) - 1
-
-			var err error
-			dst, err = splitQueries(dst, source, op.Right)
-			if err != nil {
-				return nil, err
-			}
-			lastSubquery = dst[len(dst)-1]
-
 			flavorName := "innerunique"
 			if op.Flavor != nil {
 				flavorName = op.Flavor.Name
 			}
-
-			joinSource := new(strings.Builder)
-			if flavorName == "innerunique" {
-				joinSource.WriteString("(SELECT DISTINCT * FROM ")
-			}
-			if leftSubquery >= dstStart {
-				quoteIdentifier(joinSource, dst[leftSubquery].name)
-			} else {
-				if err := dataSourceSQL(joinSource, expr.Source); err != nil {
-					return nil, err
-				}
-			}
-			if flavorName == "innerunique" {
-				joinSource.WriteString(")")
-			}
-			joinSource.WriteString(` AS "` + leftJoinTableAlias + `"`)
-
+			var sqlJoin string
 			switch flavorName {
 			case "inner", "innerunique":
-				joinSource.WriteString(" JOIN ")
+				sqlJoin = "JOIN"
 			case "leftouter":
-				joinSource.WriteString(" LEFT JOIN ")
+				sqlJoin = "LEFT JOIN"
 			default:
 				return nil, &compileError{
-					source: source,
+					source: ctx.source,
 					span:   op.Flavor.Span(),
 					err:    fmt.Errorf("unhandled join type %q", flavorName),
 				}
 			}
-			quoteIdentifier(joinSource, lastSubquery.name)
 
-			joinSource.WriteString(` AS "` + rightJoinTableAlias + `" ON `)
+			var joinSource *strings.Builder
+			var err error
+			dst, joinSource, err = splitJoin(dst, ctx, dstStart, expr.Source, op.Right, sqlJoin, flavorName == "innerunique")
+			if err != nil {
+				return nil, err
+			}
+			joinSource.WriteString(" ON ")
 			joinCtx := &exprContext{
-				source: source,
+				source: ctx.source,
 				mode:   joinExprMode,
 			}
 			if err := writeExpression(joinCtx, joinSource, buildJoinCondition(op.Conditions)); err != nil {
@@ -241,22 +474,125 @@ func splitQueries(dst []*subquery, source string, expr *parser.TabularExpr) ([]*
 				name:      subqueryName(len(dst)),
 				sourceSQL: joinSource.String(),
 			}
+			serialized = false
 			dst = append(dst, lastSubquery)
+		case *parser.LookupOperator:
+			flavorName := "leftouter"
+			if op.Flavor != nil {
+				flavorName = op.Flavor.Name
+			}
+			var sqlJoin string
+			switch flavorName {
+			case "inner":
+				sqlJoin = "JOIN"
+			case "leftouter":
+				sqlJoin = "LEFT JOIN"
+			default:
+				return nil, &compileError{
+					source: ctx.source,
+					span:   op.Flavor.Span(),
+					err:    fmt.Errorf("unhandled lookup type %q", flavorName),
+				}
+			}
+
+			var joinSource *strings.Builder
+			var err error
+			dst, joinSource, err = splitJoin(dst, ctx, dstStart, expr.Source, op.Right, sqlJoin, false)
+			if err != nil {
+				return nil, err
+			}
+			if keys := lookupKeyColumns(op.Conditions); keys != nil {
+				// USING keeps a single copy of each key column,
+				// so the left columns pass through unchanged.
+				joinSource.WriteString(" USING (")
+				for i, k := range keys {
+					if i > 0 {
+						joinSource.WriteString(", ")
+					}
+					quoteIdentifier(joinSource, k)
+				}
+				joinSource.WriteString(")")
+			} else {
+				joinSource.WriteString(" ON ")
+				joinCtx := &exprContext{
+					source: ctx.source,
+					mode:   joinExprMode,
+				}
+				if err := writeExpression(joinCtx, joinSource, buildJoinCondition(op.Conditions)); err != nil {
+					return nil, err
+				}
+			}
+
+			lastSubquery = &subquery{
+				name:      subqueryName(len(dst)),
+				sourceSQL: joinSource.String(),
+			}
+			serialized = false
+			dst = append(dst, lastSubquery)
+		case *parser.UnionOperator:
+			unionSource := new(strings.Builder)
+			unionSource.WriteString("(SELECT * FROM ")
+			if len(dst) > dstStart {
+				quoteIdentifier(unionSource, dst[len(dst)-1].name)
+			} else {
+				if err := dataSourceSQL(ctx, unionSource, expr.Source); err != nil {
+					return nil, err
+				}
+			}
+			for _, table := range op.Tables {
+				var tableName string
+				var err error
+				dst, tableName, err = splitNestedQuery(dst, ctx, table)
+				if err != nil {
+					return nil, err
+				}
+				unionSource.WriteString(" UNION ALL SELECT * FROM ")
+				quoteIdentifier(unionSource, tableName)
+			}
+			unionSource.WriteString(")")
+
+			lastSubquery = &subquery{
+				name:      subqueryName(len(dst)),
+				sourceSQL: unionSource.String(),
+			}
+			serialized = false
+			dst = append(dst, lastSubquery)
+		case *parser.SerializeOperator:
+			// Serialize does not change the rows,
+			// but it permits window functions in subsequent operators.
+			// Those must not attach to the previous subquery,
+			// which was not serialized.
+			serialized = true
+			lastSubquery = nil
+		case *parser.GetSchemaOperator:
+			// The compiler does not know table schemas,
+			// and schema introspection varies too much between SQL engines.
+			return nil, &compileError{
+				source: ctx.source,
+				span:   op.Span(),
+				err:    fmt.Errorf("getschema is not supported for SQL targets"),
+			}
 		default:
 			var err error
-			lastSubquery, err = chainSubquery(dst, dstStart, expr.Source)
+			lastSubquery, err = chainSubquery(ctx, dst, dstStart, expr.Source)
 			if err != nil {
 				return nil, err
 			}
 			lastSubquery.op = op
+			lastSubquery.serialized = serialized
 			dst = append(dst, lastSubquery)
+
+			switch op.(type) {
+			case *parser.SummarizeOperator, *parser.CountOperator:
+				serialized = false
+			}
 		}
 	}
 
 	if len(dst) == dstStart {
 		// Ensure that we add at least one subquery.
 		var err error
-		lastSubquery, err = chainSubquery(dst, dstStart, expr.Source)
+		lastSubquery, err = chainSubquery(ctx, dst, dstStart, expr.Source)
 		if err != nil {
 			return nil, err
 		}
@@ -269,7 +605,7 @@ func splitQueries(dst []*subquery, source string, expr *parser.TabularExpr) ([]*
 // chainSubquery returns a new subquery
 // that either reads from the previous subquery
 // or from the data source if there is no previous subquery.
-func chainSubquery(dst []*subquery, dstStart int, src parser.TabularDataSource) (*subquery, error) {
+func chainSubquery(ctx *exprContext, dst []*subquery, dstStart int, src parser.TabularDataSource) (*subquery, error) {
 	sub := &subquery{
 		name: subqueryName(len(dst)),
 	}
@@ -277,7 +613,7 @@ func chainSubquery(dst []*subquery, dstStart int, src parser.TabularDataSource)
 	if len(dst) > dstStart {
 		quoteIdentifier(sb, dst[len(dst)-1].name)
 	} else {
-		if err := dataSourceSQL(sb, src); err != nil {
+		if err := dataSourceSQL(ctx, sb, src); err != nil {
 			return nil, err
 		}
 	}
@@ -285,6 +621,182 @@ func chainSubquery(dst []*subquery, dstStart int, src parser.TabularDataSource)
 	return sub, nil
 }
 
+// splitJoin splits the right side of a join or lookup into subqueries
+// and returns the SQL that joins it to the left side
+// (either the previous subquery or the data source),
+// up to but not including the join condition.
+// The left side's rows are deduplicated if distinctLeft is true.
+func splitJoin(dst []*subquery, ctx *exprContext, dstStart int, src parser.TabularDataSource, right *parser.TabularExpr, sqlJoin string, distinctLeft bool) (_ []*subquery, _ *strings.Builder, err error) {
+	leftSubquery := len(dst) - 1
+	var rightName string
+	dst, rightName, err = splitNestedQuery(dst, ctx, right)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	joinSource := new(strings.Builder)
+	if distinctLeft {
+		joinSource.WriteString("(SELECT DISTINCT * FROM ")
+	}
+	if leftSubquery >= dstStart {
+		quoteIdentifier(joinSource, dst[leftSubquery].name)
+	} else {
+		if err := dataSourceSQL(ctx, joinSource, src); err != nil {
+			return nil, nil, err
+		}
+	}
+	if distinctLeft {
+		joinSource.WriteString(")")
+	}
+	joinSource.WriteString(` AS "` + leftJoinTableAlias + `" `)
+	joinSource.WriteString(sqlJoin)
+	joinSource.WriteString(" ")
+	quoteIdentifier(joinSource, rightName)
+	joinSource.WriteString(` AS "` + rightJoinTableAlias + `"`)
+	return dst, joinSource, nil
+}
+
+// splitNestedQuery splits a tabular expression nested inside another
+// (e.g. the right side of a join) into subqueries,
+// returning the name of the subquery that holds its result.
+// If a structurally identical expression has already been split,
+// then its subquery is reused instead of emitting a duplicate,
+// unless the expression is nondeterministic (e.g. it uses sample).
+func splitNestedQuery(dst []*subquery, ctx *exprContext, expr *parser.TabularExpr) (_ []*subquery, name string, err error) {
+	var syntax []string
+	if ctx.nestedQueries != nil && isDeterministic(expr) {
+		syntax = syntaxKeys(expr)
+		for _, prev := range *ctx.nestedQueries {
+			if slices.Equal(prev.syntax, syntax) {
+				return dst, prev.name, nil
+			}
+		}
+	}
+	dst, err = splitQueries(dst, ctx, expr)
+	if err != nil {
+		return nil, "", err
+	}
+	name = dst[len(dst)-1].name
+	if syntax != nil {
+		*ctx.nestedQueries = append(*ctx.nestedQueries, nestedQuery{syntax, name})
+	}
+	return dst, name, nil
+}
+
+// isDeterministic reports whether the tabular expression
+// produces the same rows each time it is evaluated,
+// so that its result can be shared.
+func isDeterministic(expr *parser.TabularExpr) bool {
+	result := true
+	parser.Walk(expr, func(n parser.Node) bool {
+		switch n := n.(type) {
+		case *parser.SampleOperator:
+			result = false
+		case *parser.CallExpr:
+			if n.Func.Name == "rand" {
+				result = false
+			}
+		}
+		return result
+	})
+	return result
+}
+
+// syntaxKeys returns the [syntaxKey] of each node in the syntax tree n
+// in the order visited by [parser.Walk].
+// Two syntax trees are structurally identical
+// (i.e. equal without regard to their positions in the source)
+// if and only if their syntax keys are equal.
+func syntaxKeys(n parser.Node) []string {
+	var keys []string
+	parser.Walk(n, func(n parser.Node) bool {
+		keys = append(keys, syntaxKey(n))
+		return true
+	})
+	return keys
+}
+
+// syntaxKey returns a string that identifies the type of n,
+// its fields other than spans and child nodes,
+// and the number of children that [parser.Walk] visits.
+func syntaxKey(n parser.Node) string {
+	switch n := n.(type) {
+	case *parser.Ident:
+		if n == nil {
+			return "Ident nil"
+		}
+		return fmt.Sprintf("Ident %q %t", n.Name, n.Quoted)
+	case *parser.QualifiedIdent:
+		return fmt.Sprintf("QualifiedIdent %d", len(n.Parts))
+	case *parser.TabularExpr:
+		return fmt.Sprintf("TabularExpr %d", len(n.Operators))
+	case *parser.TableRef:
+		return fmt.Sprintf("TableRef %d", len(n.Qualifiers))
+	case *parser.RangeSource:
+		return fmt.Sprintf("RangeSource %t %t %t %t", n.Column != nil, n.Start != nil, n.Stop != nil, n.StepSize != nil)
+	case *parser.PrintSource:
+		return fmt.Sprintf("PrintSource %d", len(n.Cols))
+	case *parser.DatatableSource:
+		return fmt.Sprintf("DatatableSource %d %d", len(n.Cols), len(n.Values))
+	case *parser.ExternalDataSource:
+		return fmt.Sprintf("ExternalDataSource %d %d %d", len(n.Cols), len(n.URIs), len(n.Properties))
+	case *parser.DatatableColumn:
+		return fmt.Sprintf("DatatableColumn %t", n.Type != nil)
+	case *parser.CountOperator:
+		return fmt.Sprintf("CountOperator %t", n.Name != nil)
+	case *parser.SortOperator:
+		return fmt.Sprintf("SortOperator %d", len(n.Terms))
+	case *parser.SortTerm:
+		return fmt.Sprintf("SortTerm %t %t", n.Asc, n.NullsFirst)
+	case *parser.SearchOperator:
+		return fmt.Sprintf("SearchOperator %t %t", n.Column != nil, n.Term != nil)
+	case *parser.ProjectOperator:
+		return fmt.Sprintf("ProjectOperator %d", len(n.Cols))
+	case *parser.ProjectColumn:
+		return fmt.Sprintf("ProjectColumn %t", n.X != nil)
+	case *parser.ExtendOperator:
+		return fmt.Sprintf("ExtendOperator %d", len(n.Cols))
+	case *parser.ExtendColumn:
+		return fmt.Sprintf("ExtendColumn %t", n.X != nil)
+	case *parser.SummarizeOperator:
+		return fmt.Sprintf("SummarizeOperator %d %d", len(n.Cols), len(n.GroupBy))
+	case *parser.SummarizeColumn:
+		return fmt.Sprintf("SummarizeColumn %t", n.Name != nil)
+	case *parser.JoinOperator:
+		// Walk does not visit Flavor.
+		flavor := ""
+		if n.Flavor != nil {
+			flavor = n.Flavor.Name
+		}
+		return fmt.Sprintf("JoinOperator %q %d", flavor, len(n.Conditions))
+	case *parser.LookupOperator:
+		// Walk does not visit Flavor.
+		flavor := ""
+		if n.Flavor != nil {
+			flavor = n.Flavor.Name
+		}
+		return fmt.Sprintf("LookupOperator %q %d %t", flavor, len(n.Conditions), n.Right != nil)
+	case *parser.InvokeOperator:
+		return fmt.Sprintf("InvokeOperator %t", n.Call != nil)
+	case *parser.UnionOperator:
+		return fmt.Sprintf("UnionOperator %d", len(n.Tables))
+	case *parser.BinaryExpr:
+		return fmt.Sprintf("BinaryExpr %v", n.Op)
+	case *parser.UnaryExpr:
+		return fmt.Sprintf("UnaryExpr %v", n.Op)
+	case *parser.InExpr:
+		return fmt.Sprintf("InExpr %d %t %t", len(n.Vals), n.CaseInsensitive, n.Negated)
+	case *parser.BasicLit:
+		return fmt.Sprintf("BasicLit %v %q", n.Kind, n.Value)
+	case *parser.CallExpr:
+		// Walk does not visit Func.
+		return fmt.Sprintf("CallExpr %q %d", n.Func.Name, len(n.Args))
+	default:
+		// The remaining nodes have a fixed set of children.
+		return fmt.Sprintf("%T", n)
+	}
+}
+
 func subqueryName(i int) string {
 	return fmt.Sprintf("__subquery%d", i)
 }
@@ -294,7 +806,7 @@ func subqueryName(i int) string {
 // because they change the identifiers in scope.
 func canAttachSort(op parser.TabularOperator) bool {
 	switch op.(type) {
-	case *parser.ProjectOperator, *parser.SummarizeOperator, *parser.AsOperator:
+	case *parser.ProjectOperator, *parser.SummarizeOperator, *parser.AsOperator, *parser.SampleOperator:
 		return false
 	default:
 		return true
@@ -322,27 +834,61 @@ func buildJoinCondition(conds []parser.Expr) parser.Expr {
 }
 
 func rewriteSimpleJoinCondition(c parser.Expr) parser.Expr {
-	id, ok := c.(*parser.QualifiedIdent)
-	if !ok || len(id.Parts) != 1 || id.Parts[0].Quoted || builtinIdentifiers[id.Parts[0].Name] != "" {
+	id := joinKeyColumn(c)
+	if id == nil {
 		return c
 	}
 	return &parser.BinaryExpr{
 		X: &parser.QualifiedIdent{
 			Parts: []*parser.Ident{
 				{Name: leftJoinTableAlias},
-				id.Parts[0],
+				id,
 			},
 		},
 		Op: parser.TokenEq,
 		Y: &parser.QualifiedIdent{
 			Parts: []*parser.Ident{
 				{Name: rightJoinTableAlias},
-				id.Parts[0],
+				id,
 			},
 		},
 	}
 }
 
+// lookupKeyColumns returns the column names of the lookup conditions
+// if every condition is a bare column name.
+// Otherwise, lookupKeyColumns returns nil.
+func lookupKeyColumns(conds []parser.Expr) []string {
+	if len(conds) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(conds))
+	for _, c := range conds {
+		id := joinKeyColumn(c)
+		if id == nil {
+			return nil
+		}
+		keys = append(keys, id.Name)
+	}
+	return keys
+}
+
+// joinKeyColumn returns the column named by a join or lookup condition
+// that is just a column name (e.g. "on id" or "on ['id']"),
+// which matches rows with equal values of the column on both sides.
+// Otherwise, joinKeyColumn returns nil.
+func joinKeyColumn(c parser.Expr) *parser.Ident {
+	id, ok := c.(*parser.QualifiedIdent)
+	if !ok || len(id.Parts) != 1 {
+		return nil
+	}
+	part := id.Parts[0]
+	if !part.Quoted && builtinIdentifiers[part.Name] != "" {
+		return nil
+	}
+	return part
+}
+
 func hasJoinTerms(x parser.Expr) (left, right bool) {
 	parser.Walk(x, func(n parser.Node) bool {
 		if n, ok := n.(*parser.Ident); ok {
@@ -359,6 +905,12 @@ func hasJoinTerms(x parser.Expr) (left, right bool) {
 }
 
 func (sub *subquery) write(ctx *exprContext, sb *strings.Builder) error {
+	if sub.serialized {
+		serializedContext := *ctx
+		serializedContext.serialized = true
+		ctx = &serializedContext
+	}
+
 	switch op := sub.op.(type) {
 	case nil, *parser.AsOperator:
 		sb.WriteString("SELECT * FROM ")
@@ -406,6 +958,9 @@ func (sub *subquery) write(ctx *exprContext, sb *strings.Builder) error {
 		sb.WriteString(" FROM ")
 		sb.WriteString(sub.sourceSQL)
 	case *parser.SummarizeOperator:
+		summarizeCtx := *ctx
+		summarizeCtx.windowsForbiddenIn = "summarize"
+		ctx := &summarizeCtx
 		sb.WriteString("SELECT ")
 		for i, col := range op.GroupBy {
 			if i > 0 {
@@ -457,12 +1012,43 @@ func (sub *subquery) write(ctx *exprContext, sb *strings.Builder) error {
 		sb.WriteString("SELECT * FROM ")
 		sb.WriteString(sub.sourceSQL)
 		sb.WriteString(" WHERE ")
-		if err := writeExpression(ctx, sb, op.Predicate); err != nil {
+		whereCtx := *ctx
+		whereCtx.windowsForbiddenIn = "where"
+		if err := writeExpression(&whereCtx, sb, op.Predicate); err != nil {
 			return err
 		}
+	case *parser.SearchOperator:
+		if op.Column == nil {
+			// Searching all columns requires knowing the table's schema.
+			return &compileError{
+				source: ctx.source,
+				span:   op.Span(),
+				err:    fmt.Errorf("search must be restricted to a column (e.g. search col:%q) in SQL", op.Term.Value),
+			}
+		}
+		sb.WriteString("SELECT * FROM ")
+		sb.WriteString(sub.sourceSQL)
+		sb.WriteString(" WHERE position(lower(")
+		quoteSQLString(sb, op.Term.Value)
+		sb.WriteString(") IN lower(")
+		quoteIdentifier(sb, op.Column.Name)
+		sb.WriteString(")) > 0")
 	case *parser.CountOperator:
-		sb.WriteString(`SELECT COUNT(*) AS "count()" FROM `)
+		sb.WriteString(`SELECT COUNT(*) AS `)
+		if op.Name != nil {
+			quoteIdentifier(sb, op.Name.Name)
+		} else {
+			quoteIdentifier(sb, "count()")
+		}
+		sb.WriteString(" FROM ")
 		sb.WriteString(sub.sourceSQL)
+	case *parser.SampleOperator:
+		sb.WriteString("SELECT * FROM ")
+		sb.WriteString(sub.sourceSQL)
+		sb.WriteString(" ORDER BY rand() LIMIT ")
+		if err := writeExpression(ctx, sb, op.RowCount); err != nil {
+			return err
+		}
 	default:
 		fmt.Fprintf(sb, "SELECT NULL /* unsupported operator %T */", op)
 		return nil
@@ -497,19 +1083,164 @@ func (sub *subquery) write(ctx *exprContext, sb *strings.Builder) error {
 		}
 	}
 
+	if sub.skip != nil {
+		sb.WriteString(" OFFSET ")
+		if err := writeExpression(ctx, sb, sub.skip.RowCount); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func dataSourceSQL(sb *strings.Builder, src parser.TabularDataSource) error {
+func dataSourceSQL(ctx *exprContext, sb *strings.Builder, src parser.TabularDataSource) error {
 	switch src := src.(type) {
 	case *parser.TableRef:
+		if len(src.Qualifiers) == 0 && ctx.referencedTables != nil {
+			ctx.referencedTables[src.Table.Name] = struct{}{}
+		}
+		for _, part := range src.Qualifiers {
+			quoteIdentifier(sb, part.Name)
+			sb.WriteString(".")
+		}
 		quoteIdentifier(sb, src.Table.Name)
 		return nil
+	case *parser.RangeSource:
+		if step, ok := numberLiteralValue(src.StepSize); ok && step == 0 {
+			return &compileError{
+				source: ctx.source,
+				span:   src.StepSize.Span(),
+				err:    fmt.Errorf("range step must not be zero"),
+			}
+		}
+		sb.WriteString(`(SELECT "generate_series" AS `)
+		quoteIdentifier(sb, src.Column.Name)
+		sb.WriteString(" FROM generate_series(")
+		for i, x := range []parser.Expr{src.Start, src.Stop, src.StepSize} {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			if err := writeExpression(ctx, sb, x); err != nil {
+				return err
+			}
+		}
+		sb.WriteString("))")
+		return nil
+	case *parser.PrintSource:
+		sb.WriteString("(SELECT ")
+		for i, col := range src.Cols {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			if err := writeExpression(ctx, sb, col.X); err != nil {
+				return err
+			}
+			sb.WriteString(" AS ")
+			if col.Name != nil {
+				quoteIdentifier(sb, col.Name.Name)
+			} else {
+				quoteIdentifier(sb, fmt.Sprintf("print_%d", i))
+			}
+		}
+		sb.WriteString(")")
+		return nil
+	case *parser.DatatableSource:
+		sb.WriteString("(")
+		if len(src.Values) == 0 {
+			sb.WriteString("SELECT ")
+			for i, col := range src.Cols {
+				if i > 0 {
+					sb.WriteString(", ")
+				}
+				sb.WriteString("NULL AS ")
+				quoteIdentifier(sb, col.Name.Name)
+			}
+			sb.WriteString(" WHERE FALSE)")
+			return nil
+		}
+		for i, x := range src.Values {
+			col := i % len(src.Cols)
+			switch {
+			case i == 0:
+				sb.WriteString("SELECT ")
+			case col == 0:
+				sb.WriteString(" UNION ALL SELECT ")
+			default:
+				sb.WriteString(", ")
+			}
+			if err := writeExpression(ctx, sb, x); err != nil {
+				return err
+			}
+			if i < len(src.Cols) {
+				// Only the first row needs column names.
+				sb.WriteString(" AS ")
+				quoteIdentifier(sb, src.Cols[col].Name.Name)
+			}
+		}
+		sb.WriteString(")")
+		return nil
+	case *parser.ExternalDataSource:
+		return &compileError{
+			source: ctx.source,
+			span:   src.Keyword,
+			err:    fmt.Errorf("externaldata is not supported for SQL targets; load the data into a table first"),
+		}
 	default:
 		return fmt.Errorf("unhandled data source %T", src)
 	}
 }
 
+// recordInTableReferences adds the names of the unqualified tables
+// read by in_table calls in n to tables.
+func recordInTableReferences(tables map[string]struct{}, n parser.Node) {
+	parser.Walk(n, func(n parser.Node) bool {
+		call, ok := n.(*parser.CallExpr)
+		if !ok || call.Func.Name != "in_table" || len(call.Args) == 0 {
+			return true
+		}
+		lit, ok := call.Args[0].(*parser.BasicLit)
+		if !ok || lit.Kind != parser.TokenString {
+			return true
+		}
+		if parts := splitTableName(lit.Value); len(parts) == 1 {
+			tables[parts[0]] = struct{}{}
+		}
+		return true
+	})
+}
+
+// numberLiteralValue returns the value of x
+// if it is a number literal with an optional sign.
+func numberLiteralValue(x parser.Expr) (_ float64, ok bool) {
+	sign := 1.0
+	for {
+		switch x2 := x.(type) {
+		case *parser.ParenExpr:
+			x = x2.X
+		case *parser.UnaryExpr:
+			switch x2.Op {
+			case parser.TokenPlus:
+			case parser.TokenMinus:
+				sign = -sign
+			default:
+				return 0, false
+			}
+			x = x2.X
+		case *parser.BasicLit:
+			if x2.Kind != parser.TokenNumber {
+				return 0, false
+			}
+			f, err := strconv.ParseFloat(x2.Value, 64)
+			if err != nil {
+				return 0, false
+			}
+			return sign * f, true
+		default:
+			return 0, false
+		}
+	}
+}
+
 func quoteIdentifier(sb *strings.Builder, name string) {
 	const quoteEscape = `""`
 	sb.Grow(len(name) + strings.Count(name, `"`)*(len(quoteEscape)-1) + len(`""`))
@@ -557,6 +1288,29 @@ type exprContext struct {
 	source string
 	scope  map[string]string
 	mode   exprMode
+	// serialized is true if window functions like row_number() are permitted.
+	serialized bool
+	// windowsForbiddenIn is the name of the operator being written
+	// if it translates to clauses that cannot contain window functions
+	// (e.g. "where" translates to a WHERE clause).
+	windowsForbiddenIn string
+	// nestedQueries is the list of nested tabular expressions
+	// (e.g. the right side of a join) that have been split into subqueries.
+	// If nil, nested tabular expressions are not deduplicated.
+	nestedQueries *[]nestedQuery
+	// referencedTables is the set of unqualified table names
+	// that have been read from so far,
+	// either as a data source or with in_table.
+	// If nil, table references are not tracked.
+	referencedTables map[string]struct{}
+}
+
+// A nestedQuery is a nested tabular expression
+// along with the name of the subquery that holds its result.
+type nestedQuery struct {
+	// syntax is the expression's syntaxKeys.
+	syntax []string
+	name   string
 }
 
 func writeExpression(ctx *exprContext, sb *strings.Builder, x parser.Expr) error {
@@ -567,7 +1321,7 @@ func writeExpression(ctx *exprContext, sb *strings.Builder, x parser.Expr) error
 		if !ok {
 			break
 		}
-		x = p
+		x = p.X
 	}
 
 	switch x := x.(type) {
@@ -690,6 +1444,66 @@ func writeExpression(ctx *exprContext, sb *strings.Builder, x parser.Expr) error
 				return err
 			}
 			sb.WriteString(")")
+		case parser.TokenContains, parser.TokenContainsCS:
+			if err := writeStringPosition(ctx, sb, x, x.Op == parser.TokenContains); err != nil {
+				return err
+			}
+			sb.WriteString(" > 0")
+		case parser.TokenStartsWith, parser.TokenStartsWithCS:
+			if err := writeStringPosition(ctx, sb, x, x.Op == parser.TokenStartsWith); err != nil {
+				return err
+			}
+			sb.WriteString(" = 1")
+		case parser.TokenEndsWith, parser.TokenEndsWithCS:
+			caseInsensitive := x.Op == parser.TokenEndsWith
+			sb.WriteString("right(")
+			if err := writeMaybeLower(ctx, sb, x.X, caseInsensitive); err != nil {
+				return err
+			}
+			sb.WriteString(", length(")
+			if err := writeExpression(ctx, sb, x.Y); err != nil {
+				return err
+			}
+			sb.WriteString(")) = ")
+			if err := writeMaybeLower(ctx, sb, x.Y, caseInsensitive); err != nil {
+				return err
+			}
+		case parser.TokenHas, parser.TokenHasCS:
+			// There is no portable SQL for matching whole terms,
+			// so use the ClickHouse token search functions.
+			if x.Op == parser.TokenHas {
+				sb.WriteString("hasTokenCaseInsensitive(")
+			} else {
+				sb.WriteString("hasToken(")
+			}
+			if err := writeExpression(ctx, sb, x.X); err != nil {
+				return err
+			}
+			sb.WriteString(", ")
+			if err := writeExpression(ctx, sb, x.Y); err != nil {
+				return err
+			}
+			sb.WriteString(")")
+		case parser.TokenMatchesRegex:
+			if lit, ok := x.Y.(*parser.BasicLit); ok && lit.Kind == parser.TokenString {
+				// ClickHouse uses RE2 syntax, same as Go.
+				if _, err := regexp.Compile(lit.Value); err != nil {
+					return &compileError{
+						source: ctx.source,
+						span:   lit.Span(),
+						err:    fmt.Errorf("invalid regular expression %q: %v", lit.Value, err),
+					}
+				}
+			}
+			sb.WriteString("match(")
+			if err := writeExpression(ctx, sb, x.X); err != nil {
+				return err
+			}
+			sb.WriteString(", ")
+			if err := writeExpression(ctx, sb, x.Y); err != nil {
+				return err
+			}
+			sb.WriteString(")")
 		case parser.TokenCaseInsensitiveNE:
 			sb.WriteString("lower(")
 			if err := writeExpression(ctx, sb, x.X); err != nil {
@@ -716,16 +1530,36 @@ func writeExpression(ctx *exprContext, sb *strings.Builder, x parser.Expr) error
 			}
 		}
 	case *parser.InExpr:
-		if err := writeExpressionMaybeParen(ctx, sb, x.X); err != nil {
-			return err
+		if x.CaseInsensitive {
+			sb.WriteString("lower(")
+			if err := writeExpression(ctx, sb, x.X); err != nil {
+				return err
+			}
+			sb.WriteString(")")
+		} else {
+			if err := writeExpressionMaybeParen(ctx, sb, x.X); err != nil {
+				return err
+			}
+		}
+		if x.Negated {
+			sb.WriteString(" NOT IN (")
+		} else {
+			sb.WriteString(" IN (")
 		}
-		sb.WriteString(" IN (")
 		for i, y := range x.Vals {
 			if i > 0 {
 				sb.WriteString(", ")
 			}
-			if err := writeExpressionMaybeParen(ctx, sb, y); err != nil {
-				return err
+			if x.CaseInsensitive {
+				sb.WriteString("lower(")
+				if err := writeExpression(ctx, sb, y); err != nil {
+					return err
+				}
+				sb.WriteString(")")
+			} else {
+				if err := writeExpressionMaybeParen(ctx, sb, y); err != nil {
+					return err
+				}
 			}
 		}
 		sb.WriteString(")")
@@ -762,6 +1596,35 @@ func writeExpression(ctx *exprContext, sb *strings.Builder, x parser.Expr) error
 	return nil
 }
 
+// writeStringPosition writes a SQL expression that evaluates to
+// the 1-based position of the binary expression's right operand
+// in its left operand, or 0 if it does not occur.
+func writeStringPosition(ctx *exprContext, sb *strings.Builder, x *parser.BinaryExpr, caseInsensitive bool) error {
+	sb.WriteString("position(")
+	if err := writeMaybeLower(ctx, sb, x.Y, caseInsensitive); err != nil {
+		return err
+	}
+	sb.WriteString(" IN ")
+	if err := writeMaybeLower(ctx, sb, x.X, caseInsensitive); err != nil {
+		return err
+	}
+	sb.WriteString(")")
+	return nil
+}
+
+// writeMaybeLower writes x, wrapped in a call to lower if caseInsensitive is true.
+func writeMaybeLower(ctx *exprContext, sb *strings.Builder, x parser.Expr, caseInsensitive bool) error {
+	if !caseInsensitive {
+		return writeExpressionMaybeParen(ctx, sb, x)
+	}
+	sb.WriteString("lower(")
+	if err := writeExpression(ctx, sb, x); err != nil {
+		return err
+	}
+	sb.WriteString(")")
+	return nil
+}
+
 // writeExpressionMaybeParen writes an expression to sb,
 // surrounding it with parentheses if sufficiently complex.
 func writeExpressionMaybeParen(ctx *exprContext, sb *strings.Builder, x parser.Expr) error {
@@ -770,7 +1633,7 @@ func writeExpressionMaybeParen(ctx *exprContext, sb *strings.Builder, x parser.E
 		if !ok {
 			break
 		}
-		x = p
+		x = p.X
 	}
 
 	switch x := x.(type) {
@@ -805,17 +1668,19 @@ var knownFunctions struct {
 func initKnownFunctions() map[string]*functionRewrite {
 	knownFunctions.init.Do(func() {
 		knownFunctions.m = map[string]*functionRewrite{
-			"count":     {write: writeCountFunction},
-			"countif":   {write: writeCountIfFunction},
-			"iif":       {write: writeIfFunction, needsParens: true},
-			"iff":       {write: writeIfFunction, needsParens: true},
-			"isnotnull": {write: writeIsNotNullFunction, needsParens: true},
-			"isnull":    {write: writeIsNullFunction, needsParens: true},
-			"not":       {write: writeNotFunction},
-			"now":       {write: writeNowFunction},
-			"strcat":    {write: writeStrcatFunction, needsParens: true},
-			"tolower":   {write: writeToLowerFunction, needsParens: true},
-			"toupper":   {write: writeToUpperFunction, needsParens: true},
+			"count":      {write: writeCountFunction},
+			"countif":    {write: writeCountIfFunction},
+			"iif":        {write: writeIfFunction, needsParens: true},
+			"iff":        {write: writeIfFunction, needsParens: true},
+			"isnotnull":  {write: writeIsNotNullFunction, needsParens: true},
+			"isnull":     {write: writeIsNullFunction, needsParens: true},
+			"in_table":   {write: writeInTableFunction, needsParens: true},
+			"not":        {write: writeNotFunction},
+			"now":        {write: writeNowFunction},
+			"row_number": {write: writeRowNumberFunction},
+			"strcat":     {write: writeStrcatFunction, needsParens: true},
+			"tolower":    {write: writeToLowerFunction, needsParens: true},
+			"toupper":    {write: writeToUpperFunction, needsParens: true},
 		}
 	})
 	return knownFunctions.m
@@ -854,6 +1719,90 @@ func writeNowFunction(ctx *exprContext, sb *strings.Builder, x *parser.CallExpr)
 	return nil
 }
 
+func writeRowNumberFunction(ctx *exprContext, sb *strings.Builder, x *parser.CallExpr) error {
+	if len(x.Args) != 0 {
+		return &compileError{
+			source: ctx.source,
+			span: parser.Span{
+				Start: x.Lparen.End,
+				End:   x.Rparen.Start,
+			},
+			err: fmt.Errorf("row_number() takes no arguments (got %d)", len(x.Args)),
+		}
+	}
+	if ctx.windowsForbiddenIn != "" {
+		return &compileError{
+			source: ctx.source,
+			span:   x.Span(),
+			err:    fmt.Errorf("row_number() cannot be used in a %s operator (assign it to a column with extend first)", ctx.windowsForbiddenIn),
+		}
+	}
+	if !ctx.serialized {
+		return &compileError{
+			source: ctx.source,
+			span:   x.Span(),
+			err:    fmt.Errorf("row_number() can only be used on serialized rows (add a serialize operator before this one)"),
+		}
+	}
+	sb.WriteString("ROW_NUMBER() OVER ()")
+	return nil
+}
+
+func writeInTableFunction(ctx *exprContext, sb *strings.Builder, x *parser.CallExpr) error {
+	if len(x.Args) != 3 {
+		return &compileError{
+			source: ctx.source,
+			span: parser.Span{
+				Start: x.Lparen.End,
+				End:   x.Rparen.Start,
+			},
+			err: fmt.Errorf("in_table(table, column, x) takes 3 arguments (got %d)", len(x.Args)),
+		}
+	}
+	var names [2]string
+	for i, arg := range x.Args[:2] {
+		lit, ok := arg.(*parser.BasicLit)
+		if !ok || lit.Kind != parser.TokenString {
+			return &compileError{
+				source: ctx.source,
+				span:   arg.Span(),
+				err:    fmt.Errorf("in_table() table and column names must be string literals"),
+			}
+		}
+		names[i] = lit.Value
+	}
+	tableParts := splitTableName(names[0])
+	if slices.Contains(tableParts, "") {
+		return &compileError{
+			source: ctx.source,
+			span:   x.Args[0].Span(),
+			err:    fmt.Errorf("in_table() table name %q is not valid", names[0]),
+		}
+	}
+	if err := writeExpressionMaybeParen(ctx, sb, x.Args[2]); err != nil {
+		return err
+	}
+	sb.WriteString(" IN (SELECT ")
+	quoteIdentifier(sb, names[1])
+	sb.WriteString(" FROM ")
+	for i, part := range tableParts {
+		if i > 0 {
+			sb.WriteString(".")
+		}
+		quoteIdentifier(sb, part)
+	}
+	sb.WriteString(")")
+	return nil
+}
+
+// splitTableName splits a table name given as a string (e.g. to in_table)
+// into its dot-separated parts.
+// The last part is the name of the table
+// and any preceding parts qualify it (e.g. "db.table").
+func splitTableName(name string) []string {
+	return strings.Split(name, ".")
+}
+
 func writeIsNullFunction(ctx *exprContext, sb *strings.Builder, x *parser.CallExpr) error {
 	if len(x.Args) != 1 {
 		return &compileError{
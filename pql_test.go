@@ -8,6 +8,654 @@ import (
 	"testing"
 )
 
+func TestPolicy(t *testing.T) {
+	functions := map[string]string{
+		"big":         "take 100000 | extend x = file('/etc/passwd') | count",
+		"florida":     "where tolower(State) == 'florida'",
+		"count_rows":  "count",
+		"read_file":   "extend x = file('/etc/passwd')",
+		"take_many":   "take 100000",
+		"nested_file": "invoke read_file()",
+	}
+	tests := []struct {
+		name   string
+		policy *Policy
+		query  string
+		fail   bool
+	}{
+		{
+			name:   "Nil",
+			policy: nil,
+			query:  "StormEvents | where foo(State) | take 1000",
+		},
+		{
+			name:   "Zero",
+			policy: new(Policy),
+			query:  "StormEvents | where foo(State) | take 1000",
+		},
+		{
+			name:   "OperatorAllowed",
+			policy: &Policy{Operators: []string{"where", "sort"}},
+			query:  "StormEvents | where State == 'TEXAS' | order by EventId",
+		},
+		{
+			name:   "OperatorDenied",
+			policy: &Policy{Operators: []string{"where"}},
+			query:  "StormEvents | where State == 'TEXAS' | count",
+			fail:   true,
+		},
+		{
+			name:   "OperatorDeniedInJoin",
+			policy: &Policy{Operators: []string{"join"}},
+			query:  "StormEvents | join (StateCapitals | take 5) on State",
+			fail:   true,
+		},
+		{
+			name:   "FunctionAllowed",
+			policy: &Policy{Functions: []string{"tolower"}},
+			query:  "StormEvents | where tolower(State) == 'texas'",
+		},
+		{
+			name:   "FunctionDenied",
+			policy: &Policy{Functions: []string{"tolower"}},
+			query:  "StormEvents | where tolower(State) == 'texas' | extend x = file('/etc/passwd')",
+			fail:   true,
+		},
+		{
+			name:   "FunctionDeniedInParens",
+			policy: &Policy{Functions: []string{"tolower"}},
+			query:  "StormEvents | where (tolower(State) == 'texas' or file('/etc/passwd') == '')",
+			fail:   true,
+		},
+		{
+			name:   "FunctionDeniedInLet",
+			policy: &Policy{Functions: []string{}},
+			query:  "let x = now(); StormEvents",
+			fail:   true,
+		},
+		{
+			name:   "TakeWithinMax",
+			policy: &Policy{MaxTake: 100},
+			query:  "StormEvents | take 100",
+		},
+		{
+			name:   "TakeAboveMax",
+			policy: &Policy{MaxTake: 100},
+			query:  "StormEvents | take 101",
+			fail:   true,
+		},
+		{
+			name:   "TopAboveMax",
+			policy: &Policy{MaxTake: 100},
+			query:  "StormEvents | top 1000 by DamageProperty",
+			fail:   true,
+		},
+		{
+			name:   "TakeOverflow",
+			policy: &Policy{MaxTake: 100},
+			query:  "StormEvents | take 100000000000000000000",
+			fail:   true,
+		},
+		{
+			name:   "RangeWithinMax",
+			policy: &Policy{MaxRangeRows: 10},
+			query:  "range i from 1 to 10 step 1",
+		},
+		{
+			name:   "RangeNegativeStepWithinMax",
+			policy: &Policy{MaxRangeRows: 10},
+			query:  "range i from 10 to -8 step -2",
+		},
+		{
+			name:   "RangeAboveMax",
+			policy: &Policy{MaxRangeRows: 10},
+			query:  "range i from 0 to 10 step 1",
+			fail:   true,
+		},
+		{
+			name:   "RangeNonLiteral",
+			policy: &Policy{MaxRangeRows: 10},
+			query:  "let n = 5; range i from 1 to n step 1",
+			fail:   true,
+		},
+		{
+			name:   "InvokeAllowed",
+			policy: &Policy{Operators: []string{"invoke", "where"}, Functions: []string{"tolower"}, MaxTake: 10},
+			query:  "StormEvents | invoke florida()",
+		},
+		{
+			name:   "InvokeDenied",
+			policy: &Policy{Operators: []string{"where"}},
+			query:  "StormEvents | invoke florida()",
+			fail:   true,
+		},
+		{
+			name:   "InvokeFragmentViolations",
+			policy: &Policy{MaxTake: 10, Operators: []string{"invoke"}, Functions: []string{"big"}},
+			query:  "StormEvents | invoke big()",
+			fail:   true,
+		},
+		{
+			name:   "InvokeFragmentOperatorDenied",
+			policy: &Policy{Operators: []string{"invoke"}},
+			query:  "StormEvents | invoke count_rows()",
+			fail:   true,
+		},
+		{
+			name:   "InvokeFragmentFunctionDenied",
+			policy: &Policy{Functions: []string{}},
+			query:  "StormEvents | invoke read_file()",
+			fail:   true,
+		},
+		{
+			name:   "InvokeNestedFragmentFunctionDenied",
+			policy: &Policy{Functions: []string{}},
+			query:  "StormEvents | invoke nested_file()",
+			fail:   true,
+		},
+		{
+			name:   "InvokeFragmentTakeAboveMax",
+			policy: &Policy{MaxTake: 100},
+			query:  "StormEvents | invoke take_many()",
+			fail:   true,
+		},
+		{
+			name:   "TakeNonLiteral",
+			policy: &Policy{MaxTake: 100},
+			query:  "let n = 10; StormEvents | take n",
+			fail:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := &CompileOptions{
+				Allow:            test.policy,
+				TabularFunctions: functions,
+			}
+			_, err := opts.Compile(test.query)
+			if err != nil {
+				if test.fail {
+					t.Logf("Compile(%q) error (as expected): %v", test.query, err)
+				} else {
+					t.Errorf("Compile(%q) returned unexpected error: %v", test.query, err)
+				}
+			}
+			if err == nil && test.fail {
+				t.Errorf("Compile(%q) did not return an error", test.query)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{
+			name:  "SearchAllColumns",
+			query: `StormEvents | search "flood"`,
+		},
+		{
+			name:  "GetSchema",
+			query: "StormEvents | getschema",
+		},
+		{
+			name:  "ExternalData",
+			query: `externaldata (a:string) ["events.csv"] | take 5`,
+		},
+		{
+			name:  "AsShadowsEarlierTable",
+			query: "StormEvents | where DamageProperty > 0 | as StormEvents | join (StormEvents | project State) on State",
+		},
+		{
+			name:  "AsShadowsJoinedTable",
+			query: "StateCapitals | join (StormEvents) on State | as StormEvents",
+		},
+		{
+			name:  "AsShadowsInTable",
+			query: "StormEvents | where in_table('StateCapitals', 'State', State) | as StateCapitals | take 1",
+		},
+		{
+			name:  "AsShadowsLetInTable",
+			query: "let florida = in_table('StateCapitals', 'State', 'FLORIDA'); StormEvents | where florida | as StateCapitals",
+		},
+		{
+			name:  "InTableNonLiteralTable",
+			query: `StormEvents | where in_table(State, "State", State)`,
+		},
+		{
+			name:  "InTableMissingArgument",
+			query: `StormEvents | where in_table("StateCapitals", State)`,
+		},
+		{
+			name:  "LetRedefined",
+			query: "let x = 1; let x = 2; StormEvents | where DamageProperty > x",
+		},
+		{
+			name:  "RangeZeroStep",
+			query: "range i from 1 to 10 step 0",
+		},
+		{
+			name:  "RangeNegativeZeroStep",
+			query: "range i from 1 to 10 step -0.0",
+		},
+		{
+			name:  "InvalidRegex",
+			query: `StormEvents | where State matches regex "(unclosed"`,
+		},
+		{
+			name:  "RowNumberWithoutSerialize",
+			query: "StormEvents | extend rn = row_number()",
+		},
+		{
+			name:  "RowNumberAfterSummarize",
+			query: "StormEvents | serialize | summarize count() by State | extend rn = row_number()",
+		},
+		{
+			name:  "RowNumberInWhere",
+			query: "StormEvents | serialize | where row_number() > 2",
+		},
+		{
+			name:  "RowNumberInSummarizeBy",
+			query: "StormEvents | serialize | summarize count() by row_number()",
+		},
+		{
+			name:  "RowNumberInAggregate",
+			query: "StormEvents | serialize | summarize max(row_number())",
+		},
+		{
+			name:  "RowNumberBeforeSerialize",
+			query: "StormEvents | extend rn = row_number() | serialize",
+		},
+		{
+			name:  "RowNumberWithArgs",
+			query: "StormEvents | serialize | extend rn = row_number(1)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Compile(test.query)
+			if err == nil {
+				t.Fatalf("Compile(%q) did not return an error", test.query)
+			}
+			t.Logf("Compile(%q) error (as expected): %v", test.query, err)
+		})
+	}
+}
+
+// TestMembership covers the combinations of membership operators
+// and value sources so that their translations do not diverge.
+func TestMembership(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+		fail  bool
+	}{
+		{
+			name:  "InSingle",
+			query: "T | where x in (1)",
+			want:  `SELECT * FROM "T" WHERE "x" IN (1);`,
+		},
+		{
+			name:  "InList",
+			query: `T | where x in ("a", "b")`,
+			want:  `SELECT * FROM "T" WHERE "x" IN ('a', 'b');`,
+		},
+		{
+			name:  "InExpressions",
+			query: "T | where x + 1 in (1, y)",
+			want:  `SELECT * FROM "T" WHERE ("x" + 1) IN (1, "y");`,
+		},
+		{
+			name:  "InNegatedWithNot",
+			query: "T | where not(x in (1, 2))",
+			want:  `SELECT * FROM "T" WHERE NOT ("x" IN (1, 2));`,
+		},
+		{
+			name:  "CaseInsensitiveInSingle",
+			query: `T | where x in~ ("US")`,
+			want:  `SELECT * FROM "T" WHERE lower("x") IN (lower('US'));`,
+		},
+		{
+			name:  "CaseInsensitiveInExpressions",
+			query: `T | where x + y in~ ("us", z)`,
+			want:  `SELECT * FROM "T" WHERE lower("x" + "y") IN (lower('us'), lower("z"));`,
+		},
+		{
+			name:  "CaseInsensitiveInEmpty",
+			query: "T | where x in~ ()",
+			fail:  true,
+		},
+		{
+			name:  "NotInSingle",
+			query: "T | where x !in (1)",
+			want:  `SELECT * FROM "T" WHERE "x" NOT IN (1);`,
+		},
+		{
+			name:  "NotInExpressions",
+			query: "T | where x + 1 !in (1, y)",
+			want:  `SELECT * FROM "T" WHERE ("x" + 1) NOT IN (1, "y");`,
+		},
+		{
+			name:  "CaseInsensitiveNotInSingle",
+			query: `T | where x !in~ ("US")`,
+			want:  `SELECT * FROM "T" WHERE lower("x") NOT IN (lower('US'));`,
+		},
+		{
+			name:  "CaseInsensitiveNotInExpressions",
+			query: `T | where x + y !in~ ("us", z)`,
+			want:  `SELECT * FROM "T" WHERE lower("x" + "y") NOT IN (lower('us'), lower("z"));`,
+		},
+		{
+			name:  "NotInEmpty",
+			query: "T | where x !in ()",
+			fail:  true,
+		},
+		{
+			name:  "CaseInsensitiveNotInEmpty",
+			query: "T | where x !in~ ()",
+			fail:  true,
+		},
+		{
+			name:  "InEmpty",
+			query: "T | where x in ()",
+			fail:  true,
+		},
+		{
+			name:  "InTable",
+			query: `T | where in_table("U", "y", x)`,
+			want:  `SELECT * FROM "T" WHERE "x" IN (SELECT "y" FROM "U");`,
+		},
+		{
+			name:  "InTableQualified",
+			query: `T | where in_table("db.U", "y", x)`,
+			want:  `SELECT * FROM "T" WHERE "x" IN (SELECT "y" FROM "db"."U");`,
+		},
+		{
+			name:  "InTableEmptyNamePart",
+			query: `T | where in_table("db.", "y", x)`,
+			fail:  true,
+		},
+		{
+			// Tabular subqueries are not supported as membership sources.
+			name:  "InSubquery",
+			query: "T | where x in (U | project y)",
+			fail:  true,
+		},
+		{
+			name:  "CaseInsensitiveInSubquery",
+			query: "T | where x in~ (U | project y)",
+			fail:  true,
+		},
+		{
+			name:  "NotInSubquery",
+			query: "T | where x !in (U | project y)",
+			fail:  true,
+		},
+		{
+			name:  "CaseInsensitiveNotInSubquery",
+			query: "T | where x !in~ (U | project y)",
+			fail:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Compile(test.query)
+			if err != nil {
+				if !test.fail {
+					t.Fatalf("Compile(%q): %v", test.query, err)
+				}
+				t.Logf("Compile(%q) error (as expected): %v", test.query, err)
+				return
+			}
+			if test.fail {
+				t.Fatalf("Compile(%q) = %q; want error", test.query, got)
+			}
+			if got != test.want {
+				t.Errorf("Compile(%q) = %q; want %q", test.query, got, test.want)
+			}
+		})
+	}
+}
+
+func TestStringOperators(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: `T | where msg contains "err"`,
+			want:  `SELECT * FROM "T" WHERE position(lower('err') IN lower("msg")) > 0;`,
+		},
+		{
+			query: `T | where msg contains_cs "err"`,
+			want:  `SELECT * FROM "T" WHERE position('err' IN "msg") > 0;`,
+		},
+		{
+			query: `T | where msg has "err"`,
+			want:  `SELECT * FROM "T" WHERE hasTokenCaseInsensitive("msg", 'err');`,
+		},
+		{
+			query: `T | where msg has_cs "err"`,
+			want:  `SELECT * FROM "T" WHERE hasToken("msg", 'err');`,
+		},
+		{
+			query: `T | where msg startswith "err"`,
+			want:  `SELECT * FROM "T" WHERE position(lower('err') IN lower("msg")) = 1;`,
+		},
+		{
+			query: `T | where msg startswith_cs "err"`,
+			want:  `SELECT * FROM "T" WHERE position('err' IN "msg") = 1;`,
+		},
+		{
+			query: `T | where msg endswith "err"`,
+			want:  `SELECT * FROM "T" WHERE right(lower("msg"), length('err')) = lower('err');`,
+		},
+		{
+			query: `T | where msg endswith_cs "err"`,
+			want:  `SELECT * FROM "T" WHERE right("msg", length('err')) = 'err';`,
+		},
+		{
+			query: `T | where url matches regex "^https?://"`,
+			want:  `SELECT * FROM "T" WHERE match("url", '^https?://');`,
+		},
+		{
+			query: `T | where url matches regex "example" and x > 1`,
+			want:  `SELECT * FROM "T" WHERE (match("url", 'example')) AND ("x" > 1);`,
+		},
+		{
+			query: `T | where a + b contains "err" and c has "x"`,
+			want:  `SELECT * FROM "T" WHERE (position(lower('err') IN lower("a" + "b")) > 0) AND (hasTokenCaseInsensitive("c", 'x'));`,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := Compile(test.query)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", test.query, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Compile(%q) = %q; want %q", test.query, got, test.want)
+		}
+	}
+}
+
+func TestTabularFunctions(t *testing.T) {
+	functions := map[string]string{
+		"florida_damage": "where State == 'FLORIDA' | extend Damaged = DamageProperty > 0",
+		"top_florida":    "invoke florida_damage() | top 1 by DamageProperty",
+		"commented":      "take 1 // only one",
+		"loop":           "invoke loop()",
+		"broken":         "where (",
+		"unserialized":   "extend n = row_number()",
+		"lower_state":    "extend tolower(State) | summarize count() by tolower(State)",
+	}
+	tests := []struct {
+		name  string
+		query string
+		// equivalent is a query without invoke operators
+		// that should produce the same SQL.
+		equivalent string
+		fail       bool
+		// errPrefix is the expected start of the error message
+		// (usually its position) if fail is true.
+		errPrefix string
+	}{
+		{
+			name:       "WhereExtend",
+			query:      "StormEvents | invoke florida_damage() | project EventId, Damaged",
+			equivalent: "StormEvents | where State == 'FLORIDA' | extend Damaged = DamageProperty > 0 | project EventId, Damaged",
+		},
+		{
+			name:       "Nested",
+			query:      "StormEvents | invoke top_florida()",
+			equivalent: "StormEvents | where State == 'FLORIDA' | extend Damaged = DamageProperty > 0 | top 1 by DamageProperty",
+		},
+		{
+			name:       "TrailingComment",
+			query:      "StormEvents | invoke commented() | project EventId",
+			equivalent: "StormEvents | take 1 | project EventId",
+		},
+		{
+			name:       "InJoin",
+			query:      "StateCapitals | join (StormEvents | invoke florida_damage()) on State",
+			equivalent: "StateCapitals | join (StormEvents | where State == 'FLORIDA' | extend Damaged = DamageProperty > 0) on State",
+		},
+		{
+			name:       "UnnamedColumns",
+			query:      "StormEvents | invoke lower_state()",
+			equivalent: "StormEvents | extend tolower(State) | summarize count() by tolower(State)",
+		},
+		{
+			name:      "Unknown",
+			query:     "StormEvents | invoke nope()",
+			fail:      true,
+			errPrefix: "1:22: ",
+		},
+		{
+			name:      "ErrorInFunction",
+			query:     "StormEvents\n| invoke unserialized()\n| take 5",
+			fail:      true,
+			errPrefix: "2:1: ",
+		},
+		{
+			name:  "Arguments",
+			query: "StormEvents | invoke florida_damage(1)",
+			fail:  true,
+		},
+		{
+			name:  "Recursive",
+			query: "StormEvents | invoke loop()",
+			fail:  true,
+		},
+		{
+			name:      "BadBody",
+			query:     "StormEvents | invoke broken()",
+			fail:      true,
+			errPrefix: "1:22: tabular function broken: parse pipeline query language: 1:8: ",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := &CompileOptions{TabularFunctions: functions}
+			got, err := opts.Compile(test.query)
+			if test.fail {
+				if err == nil {
+					t.Fatalf("Compile(%q) did not return an error", test.query)
+				}
+				t.Logf("Compile(%q) error (as expected): %v", test.query, err)
+				if !strings.HasPrefix(err.Error(), test.errPrefix) {
+					t.Errorf("error = %q; want prefix %q", err, test.errPrefix)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Compile(%q): %v", test.query, err)
+			}
+			want, err := Compile(test.equivalent)
+			if err != nil {
+				t.Fatalf("Compile(%q): %v", test.equivalent, err)
+			}
+			if got != want {
+				t.Errorf("Compile(%q) = %q; want %q", test.query, got, want)
+			}
+		})
+	}
+}
+
+func TestDuplicateSubqueries(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		// cte is a subquery definition that is expected
+		// to appear exactly count times in the output.
+		cte   string
+		count int
+	}{
+		{
+			name:  "Join",
+			query: "StormEvents | join (StateCapitals | where StateCapital != 'Atlanta') on State | join (StateCapitals  |  where StateCapital != 'Atlanta') on State",
+			cte:   `AS (SELECT * FROM "StateCapitals" WHERE coalesce("StateCapital" <> 'Atlanta', FALSE))`,
+			count: 1,
+		},
+		{
+			name:  "Union",
+			query: "StormEvents | union (StormEvents | take 1), (StormEvents | take 1)",
+			cte:   `AS (SELECT * FROM "StormEvents" LIMIT 1)`,
+			count: 1,
+		},
+		{
+			name:  "Different",
+			query: "StormEvents | union (StormEvents | take 1), (StormEvents | take 2)",
+			cte:   `AS (SELECT * FROM "StormEvents" LIMIT 1)`,
+			count: 1,
+		},
+		{
+			name:  "DifferentJoinKind",
+			query: "StormEvents | union (StormEvents | join kind=inner (StateCapitals) on State), (StormEvents | join kind=leftouter (StateCapitals) on State)",
+			cte:   ` AS "$left" LEFT JOIN `,
+			count: 1,
+		},
+		{
+			name:  "Sample",
+			query: "StormEvents | join (StateCapitals | sample 5) on State | join kind=leftouter (StateCapitals | sample 5) on State",
+			cte:   `AS (SELECT * FROM "StateCapitals" ORDER BY rand() LIMIT 5)`,
+			count: 2,
+		},
+		{
+			name:  "Rand",
+			query: "StormEvents | union (StormEvents | where rand() < 0.5), (StormEvents | where rand() < 0.5)",
+			cte:   `AS (SELECT * FROM "StormEvents" WHERE rand() < 0.5)`,
+			count: 2,
+		},
+		{
+			name:  "AfterAs",
+			query: "StormEvents | union (StateCapitals | take 1) | as Combined | union (StateCapitals | take 1)",
+			cte:   `AS (SELECT * FROM "StateCapitals" LIMIT 1)`,
+			count: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Compile(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := strings.Count(got, test.cte); n != test.count {
+				t.Errorf("Compile(%q) = %q; found %d occurrences of %q (want %d)", test.query, got, n, test.cte, test.count)
+			}
+		})
+	}
+}
+
 func TestQuoteSQLString(t *testing.T) {
 	tests := []struct {
 		s    string
@@ -0,0 +1,249 @@
+// Copyright 2024 RunReveal Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package pql
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/runreveal/pql/parser"
+)
+
+// A RewriteRule transforms a tabular expression.
+// It returns the transformed expression
+// along with a human-readable description of each change it made.
+// Rules must not modify the expression they are given:
+// they should copy any nodes they change.
+type RewriteRule func(expr *parser.TabularExpr) (*parser.TabularExpr, []string, error)
+
+// Rewrite applies the given rules to the tabular expression in order,
+// passing the result of each rule to the next.
+// It returns the final expression
+// along with the changes reported by all the rules.
+// Nodes that were not changed retain their original positions.
+func Rewrite(expr *parser.TabularExpr, rules ...RewriteRule) (*parser.TabularExpr, []string, error) {
+	var changes []string
+	for _, rule := range rules {
+		newExpr, ruleChanges, err := rule(expr)
+		if err != nil {
+			return nil, nil, err
+		}
+		expr = newExpr
+		changes = append(changes, ruleChanges...)
+	}
+	return expr, changes, nil
+}
+
+// RequireFilter returns a rule that inserts a where operator
+// with the given predicate as the first operator
+// of every tabular expression that reads from the named table,
+// including the right side of joins, lookups, and unions.
+// Table references match on their final name part,
+// so qualified references like "db.table" are also filtered.
+// The rule returns an error if the table is read in a way
+// that cannot be filtered, such as with the in_table function.
+// Unqualified column names in the predicate should be quoted
+// (i.e. [parser.Ident.Quoted] set to true)
+// so that they are not replaced by let statements or parameters.
+func RequireFilter(table string, predicate parser.Expr) RewriteRule {
+	return func(expr *parser.TabularExpr) (*parser.TabularExpr, []string, error) {
+		var err error
+		parser.Walk(expr, func(n parser.Node) bool {
+			call, ok := n.(*parser.CallExpr)
+			if !ok || call.Func.Name != "in_table" || len(call.Args) == 0 {
+				return err == nil
+			}
+			lit, ok := call.Args[0].(*parser.BasicLit)
+			if !ok || lit.Kind != parser.TokenString {
+				return err == nil
+			}
+			if parts := splitTableName(lit.Value); parts[len(parts)-1] == table {
+				err = fmt.Errorf("in_table at %v reads table %s, which requires a filter", call.Span(), table)
+			}
+			return err == nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return mapTabularExprs(expr, func(expr *parser.TabularExpr) (*parser.TabularExpr, []string, error) {
+			ref, ok := expr.Source.(*parser.TableRef)
+			if !ok || ref.Table.Name != table {
+				return expr, nil, nil
+			}
+			newExpr := *expr
+			newExpr.Operators = slices.Insert(slices.Clip(expr.Operators), 0, parser.TabularOperator(&parser.WhereOperator{
+				Pipe:      nullSpan(),
+				Keyword:   nullSpan(),
+				Predicate: predicate,
+			}))
+			return &newExpr, []string{fmt.Sprintf("added where operator after table %s", table)}, nil
+		})
+	}
+}
+
+// LimitRows returns a rule that appends a take operator with the given row count
+// to the top-level expression if it does not already end with a take or top operator.
+func LimitRows(n uint64) RewriteRule {
+	return func(expr *parser.TabularExpr) (*parser.TabularExpr, []string, error) {
+		if len(expr.Operators) > 0 {
+			switch expr.Operators[len(expr.Operators)-1].(type) {
+			case *parser.TakeOperator, *parser.TopOperator:
+				return expr, nil, nil
+			}
+		}
+		newExpr := *expr
+		newExpr.Operators = append(slices.Clip(expr.Operators), &parser.TakeOperator{
+			Pipe:    nullSpan(),
+			Keyword: nullSpan(),
+			RowCount: &parser.BasicLit{
+				ValueSpan: nullSpan(),
+				Kind:      parser.TokenNumber,
+				Value:     strconv.FormatUint(n, 10),
+			},
+		})
+		return &newExpr, []string{fmt.Sprintf("added take %d", n)}, nil
+	}
+}
+
+// DenyColumns returns a rule that removes columns from project operators
+// if they are named one of the given columns
+// or their expression references one of the given columns.
+// It is an error for a project operator to have all of its columns removed.
+func DenyColumns(columns ...string) RewriteRule {
+	isDenied := func(col *parser.ProjectColumn) bool {
+		if slices.Contains(columns, col.Name.Name) {
+			return true
+		}
+		if col.X == nil {
+			return false
+		}
+		found := false
+		parser.Walk(col.X, func(n parser.Node) bool {
+			if id, ok := n.(*parser.QualifiedIdent); ok {
+				found = found || slices.Contains(columns, id.Parts[len(id.Parts)-1].Name)
+				return false
+			}
+			return !found
+		})
+		return found
+	}
+
+	return func(expr *parser.TabularExpr) (*parser.TabularExpr, []string, error) {
+		return mapTabularExprs(expr, func(expr *parser.TabularExpr) (*parser.TabularExpr, []string, error) {
+			var newOperators []parser.TabularOperator
+			var changes []string
+			for i, op := range expr.Operators {
+				project, ok := op.(*parser.ProjectOperator)
+				if !ok {
+					continue
+				}
+				newProject := &parser.ProjectOperator{
+					Pipe:    project.Pipe,
+					Keyword: project.Keyword,
+				}
+				for _, col := range project.Cols {
+					if isDenied(col) {
+						changes = append(changes, fmt.Sprintf("removed column %s from project", col.Name.Name))
+					} else {
+						newProject.Cols = append(newProject.Cols, col)
+					}
+				}
+				if len(newProject.Cols) == len(project.Cols) {
+					continue
+				}
+				if len(newProject.Cols) == 0 {
+					return nil, nil, fmt.Errorf("all columns of project operator at %v are denied", project.Span())
+				}
+				if newOperators == nil {
+					newOperators = slices.Clone(expr.Operators)
+				}
+				newOperators[i] = newProject
+			}
+			if newOperators == nil {
+				return expr, nil, nil
+			}
+			newExpr := *expr
+			newExpr.Operators = newOperators
+			return &newExpr, changes, nil
+		})
+	}
+}
+
+// mapTabularExprs calls f on the nested tabular expressions
+// of each join, lookup, or union operator in expr
+// and then on the expression itself,
+// returning a copy of the expression with the results of f substituted.
+func mapTabularExprs(expr *parser.TabularExpr, f func(*parser.TabularExpr) (*parser.TabularExpr, []string, error)) (*parser.TabularExpr, []string, error) {
+	var newOperators []parser.TabularOperator
+	var changes []string
+	for i, op := range expr.Operators {
+		var nested []*parser.TabularExpr
+		switch op := op.(type) {
+		case *parser.JoinOperator:
+			nested = []*parser.TabularExpr{op.Right}
+		case *parser.LookupOperator:
+			nested = []*parser.TabularExpr{op.Right}
+		case *parser.UnionOperator:
+			nested = op.Tables
+		default:
+			continue
+		}
+		var newNested []*parser.TabularExpr
+		for j, x := range nested {
+			newX, nestedChanges, err := mapTabularExprs(x, f)
+			if err != nil {
+				return nil, nil, err
+			}
+			changes = append(changes, nestedChanges...)
+			if newX == x {
+				continue
+			}
+			if newNested == nil {
+				newNested = slices.Clone(nested)
+			}
+			newNested[j] = newX
+		}
+		if newNested == nil {
+			continue
+		}
+		if newOperators == nil {
+			newOperators = slices.Clone(expr.Operators)
+		}
+		switch op := op.(type) {
+		case *parser.JoinOperator:
+			newJoin := new(parser.JoinOperator)
+			*newJoin = *op
+			newJoin.Right = newNested[0]
+			newOperators[i] = newJoin
+		case *parser.LookupOperator:
+			newLookup := new(parser.LookupOperator)
+			*newLookup = *op
+			newLookup.Right = newNested[0]
+			newOperators[i] = newLookup
+		case *parser.UnionOperator:
+			newUnion := new(parser.UnionOperator)
+			*newUnion = *op
+			newUnion.Tables = newNested
+			newOperators[i] = newUnion
+		}
+	}
+	if newOperators != nil {
+		newExpr := new(parser.TabularExpr)
+		*newExpr = *expr
+		newExpr.Operators = newOperators
+		expr = newExpr
+	}
+
+	expr, exprChanges, err := f(expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return expr, append(changes, exprChanges...), nil
+}
+
+func nullSpan() parser.Span {
+	return parser.Span{Start: -1, End: -1}
+}
@@ -0,0 +1,207 @@
+// Copyright 2024 RunReveal Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package pql
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/runreveal/pql/parser"
+)
+
+func TestRewrite(t *testing.T) {
+	tenantFilter := &parser.BinaryExpr{
+		X:  (&parser.Ident{Name: "tenant_id", Quoted: true}).AsQualified(),
+		Op: parser.TokenEq,
+		Y:  (&parser.Ident{Name: "tenant"}).AsQualified(),
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		rules       []RewriteRule
+		want        string
+		wantChanges []string
+		fail        bool
+	}{
+		{
+			name:  "RequireFilter",
+			query: "events | project id",
+			rules: []RewriteRule{RequireFilter("events", tenantFilter)},
+			want: `WITH "__subquery0" AS (SELECT * FROM "events" WHERE coalesce("tenant_id" = $1, FALSE))` + "\n" +
+				`SELECT "id" AS "id" FROM "__subquery0";`,
+			wantChanges: []string{"added where operator after table events"},
+		},
+		{
+			name: "RequireFilterTrickyInput",
+			query: "// events | where tenant_id == 'other';\n" +
+				"`events` | where `tenant` == 'x' // | take 1",
+			rules: []RewriteRule{RequireFilter("events", tenantFilter)},
+			want: `WITH "__subquery0" AS (SELECT * FROM "events" WHERE coalesce("tenant_id" = $1, FALSE))` + "\n" +
+				`SELECT * FROM "__subquery0" WHERE coalesce("tenant" = 'x', FALSE);`,
+			wantChanges: []string{"added where operator after table events"},
+		},
+		{
+			name:  "RequireFilterJoin",
+			query: "users | join (events) on id",
+			rules: []RewriteRule{RequireFilter("events", tenantFilter)},
+			want: `WITH "__subquery0" AS (SELECT * FROM "events" WHERE coalesce("tenant_id" = $1, FALSE))` + "\n" +
+				`SELECT * FROM (SELECT DISTINCT * FROM "users") AS "$left" JOIN "__subquery0" AS "$right" ON "$left"."id" = "$right"."id";`,
+			wantChanges: []string{"added where operator after table events"},
+		},
+		{
+			name:  "RequireFilterQualified",
+			query: "default.events | project id",
+			rules: []RewriteRule{RequireFilter("events", tenantFilter)},
+			want: `WITH "__subquery0" AS (SELECT * FROM "default"."events" WHERE coalesce("tenant_id" = $1, FALSE))` + "\n" +
+				`SELECT "id" AS "id" FROM "__subquery0";`,
+			wantChanges: []string{"added where operator after table events"},
+		},
+		{
+			name:  "RequireFilterUnion",
+			query: "users | union (default.events | project id)",
+			rules: []RewriteRule{RequireFilter("events", tenantFilter)},
+			want: `WITH "__subquery0" AS (SELECT * FROM "default"."events" WHERE coalesce("tenant_id" = $1, FALSE)),` + "\n" +
+				`     "__subquery1" AS (SELECT "id" AS "id" FROM "__subquery0")` + "\n" +
+				`SELECT * FROM (SELECT * FROM "users" UNION ALL SELECT * FROM "__subquery1");`,
+			wantChanges: []string{"added where operator after table events"},
+		},
+		{
+			name:  "RequireFilterInTable",
+			query: "users | where in_table('events', 'id', id)",
+			rules: []RewriteRule{RequireFilter("events", tenantFilter)},
+			fail:  true,
+		},
+		{
+			name:  "RequireFilterQualifiedInTable",
+			query: "users | join (users | where in_table('default.events', 'id', id)) on id",
+			rules: []RewriteRule{RequireFilter("events", tenantFilter)},
+			fail:  true,
+		},
+		{
+			name:  "RequireFilterInTableLet",
+			query: "let x = in_table('events', 'id', 1);\nusers | where x",
+			rules: []RewriteRule{RequireFilter("events", tenantFilter)},
+			fail:  true,
+		},
+		{
+			name:        "RequireFilterInTableQualifiedOtherTable",
+			query:       "users | where in_table('events.archive', 'id', id)",
+			rules:       []RewriteRule{RequireFilter("events", tenantFilter)},
+			want:        `SELECT * FROM "users" WHERE "id" IN (SELECT "id" FROM "events"."archive");`,
+			wantChanges: nil,
+		},
+		{
+			name:        "RequireFilterInTableOtherTable",
+			query:       "events_archive | where in_table('users', 'id', id)",
+			rules:       []RewriteRule{RequireFilter("events", tenantFilter)},
+			want:        `SELECT * FROM "events_archive" WHERE "id" IN (SELECT "id" FROM "users");`,
+			wantChanges: nil,
+		},
+		{
+			name:        "RequireFilterOtherTable",
+			query:       "users",
+			rules:       []RewriteRule{RequireFilter("events", tenantFilter)},
+			want:        `SELECT * FROM "users";`,
+			wantChanges: nil,
+		},
+		{
+			name:        "LimitRows",
+			query:       "events | where x > 1",
+			rules:       []RewriteRule{LimitRows(100)},
+			want:        `SELECT * FROM "events" WHERE "x" > 1 LIMIT 100;`,
+			wantChanges: []string{"added take 100"},
+		},
+		{
+			name:        "LimitRowsExistingTake",
+			query:       "events | take 5",
+			rules:       []RewriteRule{LimitRows(100)},
+			want:        `SELECT * FROM "events" LIMIT 5;`,
+			wantChanges: nil,
+		},
+		{
+			name:  "DenyColumns",
+			query: "events | project id, email, domain = extract_domain(email), `email`",
+			rules: []RewriteRule{DenyColumns("email")},
+			want:  `SELECT "id" AS "id" FROM "events";`,
+			wantChanges: []string{
+				"removed column email from project",
+				"removed column domain from project",
+				"removed column email from project",
+			},
+		},
+		{
+			name:  "DenyAllColumns",
+			query: "events | project email",
+			rules: []RewriteRule{DenyColumns("email")},
+			fail:  true,
+		},
+		{
+			name:  "Composed",
+			query: "events | project id, email",
+			rules: []RewriteRule{
+				RequireFilter("events", tenantFilter),
+				DenyColumns("email"),
+				LimitRows(10),
+			},
+			want: `WITH "__subquery0" AS (SELECT * FROM "events" WHERE coalesce("tenant_id" = $1, FALSE)),` + "\n" +
+				`     "__subquery1" AS (SELECT "id" AS "id" FROM "__subquery0")` + "\n" +
+				`SELECT * FROM "__subquery1" LIMIT 10;`,
+			wantChanges: []string{
+				"added where operator after table events",
+				"removed column email from project",
+				"added take 10",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stmts, err := parser.Parse(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expr := stmts[len(stmts)-1].(*parser.TabularExpr)
+			_, gotChanges, err := Rewrite(expr, test.rules...)
+			if err != nil {
+				if !test.fail {
+					t.Fatal("Rewrite:", err)
+				}
+				t.Log("Rewrite error (as expected):", err)
+				return
+			}
+			if diff := cmp.Diff(test.wantChanges, gotChanges); diff != "" {
+				t.Errorf("changes (-want +got):\n%s", diff)
+			}
+
+			opts := &CompileOptions{
+				Parameters: map[string]string{"tenant": "$1"},
+				Rules:      test.rules,
+			}
+			got, err := opts.Compile(test.query)
+			if err != nil {
+				if !test.fail {
+					t.Fatal("Compile:", err)
+				}
+				t.Log("Compile error (as expected):", err)
+				return
+			}
+			if test.fail {
+				t.Fatal("Compile did not return an error")
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Compile(%q) (-want +got):\n%s", test.query, diff)
+			}
+
+			// Rewriting must not modify the original expression.
+			stmts2, err := parser.Parse(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(stmts2, stmts); diff != "" {
+				t.Errorf("Rewrite modified its input (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+WITH "__subquery0" AS (SELECT * FROM "StormEvents" WHERE "DamageProperty" > 0),
+     "Filtered" AS (SELECT * FROM "__subquery0"),
+     "__subquery2" AS (SELECT "State" AS "State", count() AS "n" FROM "Filtered" GROUP BY "State"),
+     "__subquery3" AS (SELECT * FROM (SELECT DISTINCT * FROM "Filtered") AS "$left" JOIN "__subquery2" AS "$right" ON "$left"."State" = "$right"."State"),
+     "__subquery4" AS (SELECT "EventId" AS "EventId", "State" AS "State", "n" AS "n" FROM "__subquery3")
+SELECT * FROM "__subquery4" ORDER BY "EventId" ASC NULLS FIRST;
@@ -0,0 +1 @@
+SELECT COUNT(*) AS "Total" FROM "StormEvents";
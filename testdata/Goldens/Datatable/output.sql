@@ -0,0 +1 @@
+SELECT * FROM (SELECT 'alice' AS "name", 30 AS "age" UNION ALL SELECT 'bob', 25) WHERE "age" > 26;
@@ -0,0 +1,2 @@
+WITH "__subquery0" AS (SELECT * FROM "StormEvents" WHERE ((coalesce("State" = 'FLORIDA', FALSE)) OR (coalesce("State" = 'TEXAS', FALSE))) AND ("DamageProperty" > 0))
+SELECT "EventId" AS "EventId" FROM "__subquery0";
@@ -0,0 +1,2 @@
+WITH "__subquery0" AS (SELECT * FROM "StormEvents" WHERE lower("State") IN (lower('georgia'), lower('Mississippi')))
+SELECT "EventId" AS "EventId", "State" AS "State" FROM "__subquery0";
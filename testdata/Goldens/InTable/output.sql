@@ -0,0 +1,2 @@
+WITH "__subquery0" AS (SELECT * FROM "StateCapitals" WHERE (UPPER("State")) IN (SELECT "State" FROM "StormEvents") ORDER BY "State" ASC NULLS FIRST)
+SELECT "State" AS "State" FROM "__subquery0";
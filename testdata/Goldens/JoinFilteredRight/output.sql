@@ -0,0 +1,4 @@
+WITH "__subquery0" AS (SELECT * FROM "StateCapitals" WHERE coalesce("StateCapital" <> 'Atlanta', FALSE)),
+     "__subquery1" AS (SELECT UPPER("State") AS "State", "StateCapital" AS "StateCapital" FROM "__subquery0"),
+     "__subquery2" AS (SELECT * FROM "StormEvents" AS "$left" JOIN "__subquery1" AS "$right" ON "$left"."State" = "$right"."State" ORDER BY "EventId" ASC NULLS FIRST)
+SELECT "EventId" AS "EventId", "StateCapital" AS "StateCapital" FROM "__subquery2";
@@ -0,0 +1,3 @@
+WITH "__subquery0" AS (SELECT * FROM (SELECT 'FLORIDA' AS "Name", 'South' AS "Region" UNION ALL SELECT 'GEORGIA', 'South')),
+     "__subquery1" AS (SELECT * FROM "StormEvents" AS "$left" JOIN "__subquery0" AS "$right" ON "$left"."State" = "$right"."Name" ORDER BY "EventId" ASC NULLS FIRST)
+SELECT "EventId" AS "EventId", "Region" AS "Region" FROM "__subquery1";
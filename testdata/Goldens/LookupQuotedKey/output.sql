@@ -0,0 +1,3 @@
+WITH "__subquery0" AS (SELECT * FROM (SELECT 'FLORIDA' AS "State", 'South' AS "Region" UNION ALL SELECT 'GEORGIA', 'South')),
+     "__subquery1" AS (SELECT * FROM "StormEvents" AS "$left" LEFT JOIN "__subquery0" AS "$right" USING ("State") ORDER BY "EventId" ASC NULLS FIRST)
+SELECT "EventId" AS "EventId", "State" AS "State", "Region" AS "Region" FROM "__subquery1";
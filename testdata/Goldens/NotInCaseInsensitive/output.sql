@@ -0,0 +1,2 @@
+WITH "__subquery0" AS (SELECT * FROM "StormEvents" WHERE lower("State") NOT IN (lower('florida'), lower('Atlantic South')))
+SELECT "EventId" AS "EventId", "State" AS "State" FROM "__subquery0";
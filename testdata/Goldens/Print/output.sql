@@ -0,0 +1 @@
+SELECT * FROM (SELECT 1 + 2 AS "x", 'a' || 'b' AS "msg", 42 AS "print_2");
@@ -0,0 +1 @@
+SELECT "as" AS "as" FROM "select"."order";
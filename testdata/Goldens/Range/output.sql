@@ -0,0 +1 @@
+SELECT * FROM (SELECT "generate_series" AS "i" FROM generate_series(1, 10, 3)) WHERE "i" > 1;
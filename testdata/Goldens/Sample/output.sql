@@ -0,0 +1 @@
+SELECT * FROM "StormEvents" ORDER BY rand() LIMIT 3;
@@ -0,0 +1,3 @@
+WITH "__subquery0" AS (SELECT * FROM "StormEvents" WHERE "DamageProperty" > 0),
+     "__subquery1" AS (SELECT * FROM "__subquery0" ORDER BY rand() LIMIT 3)
+SELECT * FROM "__subquery1" ORDER BY "EventId" ASC NULLS FIRST;
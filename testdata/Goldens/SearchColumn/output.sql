@@ -0,0 +1,2 @@
+WITH "__subquery0" AS (SELECT * FROM "StormEvents" WHERE position(lower('Flor') IN lower("State")) > 0)
+SELECT "EventId" AS "EventId", "State" AS "State" FROM "__subquery0";
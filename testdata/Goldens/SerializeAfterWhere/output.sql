@@ -0,0 +1,3 @@
+WITH "__subquery0" AS (SELECT * FROM "StormEvents" WHERE "DamageProperty" > 0),
+     "__subquery1" AS (SELECT * FROM "__subquery0" ORDER BY ROW_NUMBER() OVER () DESC NULLS LAST)
+SELECT "EventId" AS "EventId" FROM "__subquery1";
@@ -0,0 +1,3 @@
+WITH "__subquery0" AS (SELECT * FROM "StormEvents" ORDER BY "EventId" ASC NULLS FIRST),
+     "__subquery1" AS (SELECT *, ROW_NUMBER() OVER () AS "rn" FROM "__subquery0")
+SELECT "EventId" AS "EventId", "rn" AS "rn" FROM "__subquery1";
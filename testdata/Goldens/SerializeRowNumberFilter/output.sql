@@ -0,0 +1,3 @@
+WITH "__subquery0" AS (SELECT *, ROW_NUMBER() OVER () AS "rn" FROM "StormEvents"),
+     "__subquery1" AS (SELECT * FROM "__subquery0" WHERE "rn" > 3)
+SELECT "EventId" AS "EventId", "rn" AS "rn" FROM "__subquery1";
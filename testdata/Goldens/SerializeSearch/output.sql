@@ -0,0 +1,2 @@
+WITH "__subquery0" AS (SELECT * FROM "StormEvents" WHERE position(lower('florida') IN lower("State")) > 0 ORDER BY ROW_NUMBER() OVER () DESC NULLS LAST)
+SELECT "EventId" AS "EventId" FROM "__subquery0";
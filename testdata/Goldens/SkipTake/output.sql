@@ -0,0 +1 @@
+SELECT * FROM "StateCapitals" ORDER BY "State" ASC NULLS FIRST LIMIT 3 OFFSET 5;
@@ -0,0 +1,2 @@
+WITH "__subquery0" AS (SELECT "State" AS "State", count() AS "EventCount" FROM "StormEvents" GROUP BY "State")
+SELECT * FROM "__subquery0" ORDER BY "EventCount" DESC NULLS LAST, "State" ASC NULLS FIRST LIMIT 2;
@@ -0,0 +1,2 @@
+WITH "__subquery0" AS (SELECT * FROM "StateCapitals" ORDER BY "State" ASC NULLS FIRST LIMIT 10)
+SELECT * FROM "__subquery0" OFFSET 5;
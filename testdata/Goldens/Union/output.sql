@@ -0,0 +1,3 @@
+WITH "__subquery0" AS (SELECT * FROM "StormEvents"),
+     "__subquery1" AS (SELECT * FROM (SELECT * FROM "StormEvents" UNION ALL SELECT * FROM "__subquery0"))
+SELECT COUNT(*) AS "count()" FROM "__subquery1";
@@ -0,0 +1,4 @@
+WITH "__subquery0" AS (SELECT * FROM "StormEvents" WHERE coalesce("State" = 'FLORIDA', FALSE)),
+     "__subquery1" AS (SELECT * FROM "StormEvents" WHERE coalesce("State" = 'GEORGIA', FALSE)),
+     "__subquery2" AS (SELECT * FROM (SELECT * FROM "__subquery0" UNION ALL SELECT * FROM "__subquery1") ORDER BY "EventId" ASC NULLS FIRST)
+SELECT "EventId" AS "EventId", "State" AS "State" FROM "__subquery2";
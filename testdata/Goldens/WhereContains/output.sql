@@ -0,0 +1,2 @@
+WITH "__subquery0" AS (SELECT * FROM "StormEvents" WHERE position(lower('thunder') IN lower("EventType")) > 0)
+SELECT "EventId" AS "EventId", "EventType" AS "EventType" FROM "__subquery0";
@@ -0,0 +1,2 @@
+WITH "__subquery0" AS (SELECT * FROM "StormEvents" WHERE (hasTokenCaseInsensitive("EventType", 'rain')) OR (hasTokenCaseInsensitive("EventType", 'thunder')))
+SELECT "EventId" AS "EventId", "EventType" AS "EventType" FROM "__subquery0";